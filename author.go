@@ -0,0 +1,62 @@
+package githubcomment
+
+import (
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// Me can be used in TrustedAuthors and resolves to the authenticated user
+const Me = "@me"
+
+// trustedAuthors returns the TrustedAuthors with Me resolved to the login of the authenticated user,
+// the login is looked up on every call unless ResolveTrustedAuthors was called
+func (gc *GithubComment) trustedAuthors() ([]string, error) {
+	authors := make([]string, 0, len(gc.TrustedAuthors))
+	me := ""
+	for _, author := range gc.TrustedAuthors {
+		if author != Me {
+			authors = append(authors, author)
+			continue
+		}
+		if me == "" {
+			user, _, err := gc.Client.Users.Get(gc.Context, "")
+			if err != nil {
+				return nil, apiError(err)
+			}
+			me = user.GetLogin()
+		}
+		authors = append(authors, me)
+	}
+	return authors, nil
+}
+
+// ResolveTrustedAuthors replaces Me in the TrustedAuthors with the login of the authenticated user,
+// call it once before copying the GithubComment (e.g. for concurrent operations) so the copies do not look it up again
+func (gc *GithubComment) ResolveTrustedAuthors() error {
+	authors, err := gc.trustedAuthors()
+	if err != nil {
+		return err
+	}
+	gc.TrustedAuthors = authors
+	return nil
+}
+
+// isTrustedAuthor reports whether the user is one of the authors,
+// bot accounts can be specified with or without the [bot] suffix.
+// If no authors are specified every user is trusted.
+func isTrustedAuthor(user *github.User, authors []string) bool {
+	if len(authors) == 0 {
+		return true
+	}
+	login := user.GetLogin()
+	for _, author := range authors {
+		if strings.EqualFold(login, author) {
+			return true
+		}
+		if user.GetType() == "Bot" && strings.EqualFold(login, author+"[bot]") {
+			return true
+		}
+	}
+	return false
+}
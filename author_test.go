@@ -0,0 +1,64 @@
+package githubcomment
+
+import (
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTrustedAuthor(t *testing.T) {
+	user := &github.User{Login: github.String("Bob"), Type: github.String("User")}
+	bot := &github.User{Login: github.String("github-actions[bot]"), Type: github.String("Bot")}
+
+	require.True(t, isTrustedAuthor(user, nil))
+	require.True(t, isTrustedAuthor(user, []string{"alice", "bob"}))
+	require.False(t, isTrustedAuthor(user, []string{"alice"}))
+	require.True(t, isTrustedAuthor(bot, []string{"github-actions"}))
+	require.True(t, isTrustedAuthor(bot, []string{"github-actions[bot]"}))
+	require.False(t, isTrustedAuthor(&github.User{Login: github.String("github-actions[bot]"), Type: github.String("User")}, []string{"github-actions"}))
+}
+
+func TestTrustedAuthors(t *testing.T) {
+	f, gc := newFakeGithub(t, makeMagicMarker(ID("deploy"))+"\nissue body")
+	foreign := f.addComment("mallory", makeMagicMarker(ID("deploy"))+"\nspoofed")
+	gc.TrustedAuthors = []string{Me}
+
+	_, err := gc.GetIssueComment(1, ID("deploy"))
+	require.Equal(t, IssueCommentNotFoundError{ID: ID("deploy")}, err)
+
+	// the untrusted comment is not edited
+	result, err := gc.UpdateIssueComment(1, ID("deploy"), "Hello World", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionCreated, result.Action)
+	require.Equal(t, "bot", result.Info.Author)
+	require.Contains(t, foreign.GetBody(), "spoofed")
+
+	gc.TrustedAuthors = []string{"mallory"}
+	info, err := gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.Equal(t, foreign.GetID(), info.CommentID)
+
+	gc.TrustedAuthors = []string{"author"}
+	info, err = gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.Equal(t, LocationIssue, info.Location)
+}
+
+func TestResolveTrustedAuthors(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	f.addComment("bot", makeMagicMarker(ID("deploy"))+"\nHello World")
+	gc.TrustedAuthors = []string{Me, "alice"}
+
+	require.NoError(t, gc.ResolveTrustedAuthors())
+	require.Equal(t, []string{"bot", "alice"}, gc.TrustedAuthors)
+	require.Equal(t, 1, f.userRequests)
+
+	// copies share the resolved login
+	for i := 0; i < 3; i++ {
+		copied := *gc
+		_, err := copied.GetIssueComment(1, ID("deploy"))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, f.userRequests)
+}
@@ -6,26 +6,84 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 const magic = "github-info-id"
 
 func makeMagicMarker(id ID) string {
+	return fmt.Sprintf("<!---%s-%s--->", magic, id.Canonical())
+}
+
+// makeLegacyMagicMarker returns the marker that was used by earlier versions
+func makeLegacyMagicMarker(id ID) string {
 	return fmt.Sprintf("<!---%s-%s--->", magic, id.GetID())
 }
 
+const signatureMagic = "github-info-sig"
+
+func makeSignatureMarker(signature string) string {
+	return fmt.Sprintf("<!---%s-%s--->", signatureMagic, signature)
+}
+
 var regexID *regexp.Regexp
 var regexMeta *regexp.Regexp
+var regexSignature *regexp.Regexp
 
 func init() {
-	regexID = regexp.MustCompile(fmt.Sprintf(`<!---%s-([0-9a-zA-Z-]+)--->`, magic))
+	regexID = regexp.MustCompile(fmt.Sprintf(`<!---%s-([\p{L}\p{N}-]*(?:\.[a-z2-7]+)?)--->`, magic))
 	regexMeta = regexp.MustCompile(`^<!---(.*)--->$`)
+	regexSignature = regexp.MustCompile(fmt.Sprintf(`<!---%s-([0-9a-f]+)--->$`, signatureMagic))
+}
+
+// Location describes where a marker was found
+type Location string
+
+const (
+	// LocationIssue is used when the marker lives in the issue (or pull request) body
+	LocationIssue Location = "issue"
+	// LocationComment is used when the marker lives in a comment
+	LocationComment Location = "comment"
+)
+
+// Reactions summarizes the reactions on a comment
+type Reactions struct {
+	Total    int `json:"total"`
+	PlusOne  int `json:"+1"`
+	MinusOne int `json:"-1"`
+	Laugh    int `json:"laugh"`
+	Confused int `json:"confused"`
+	Heart    int `json:"heart"`
+	Hooray   int `json:"hooray"`
 }
 
+// Info holds the content of a comment.
+// The fields after Meta are only filled when the info was retrieved from github.
 type Info struct {
-	ID   ID
-	Body string
-	Meta interface{}
+	ID   ID          `json:"id"`
+	Body string      `json:"body"`
+	Meta interface{} `json:"meta,omitempty"`
+	// History holds the previous bodies (newest first)
+	History []HistoryEntry `json:"history,omitempty"`
+
+	// Signature is the hmac of the comment (if it was signed)
+	Signature string `json:"signature,omitempty"`
+	// Verified reports whether the signature was verified with the signing key
+	Verified bool `json:"verified,omitempty"`
+	// MetaError describes why the meta could not be decrypted (Meta is nil then) or does not match the MetaSchema,
+	// comments are still returned so their body can be read without the key and after the schema changed
+	MetaError string `json:"meta_error,omitempty"`
+	// metaErr is the error of the decryption, see MetaErr
+	metaErr error
+
+	CommentID  int64      `json:"comment_id,omitempty"`
+	Location   Location   `json:"location,omitempty"`
+	Author     string     `json:"author,omitempty"`
+	AuthorType string     `json:"author_type,omitempty"`
+	CreatedAt  *time.Time `json:"created_at,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	HTMLURL    string     `json:"html_url,omitempty"`
+	Reactions  *Reactions `json:"reactions,omitempty"`
 }
 
 // ParseInfo parses the body of a comment
@@ -36,20 +94,24 @@ func ParseInfo(raw string) (*Info, error) {
 	}
 	var info Info
 	// limit the search
-	info.Body = raw[end+1:]
+	info.Body, info.History = parseHistory(raw[end+1:])
 	raw = strings.TrimSpace(raw[:end])
 	matches := regexID.FindStringSubmatch(raw)
 	if len(matches) != 2 {
 		return nil, errors.New("no marker found (regex failure)")
 	}
 	// we found the marker
-	info.ID = ID(matches[1])
-	if info.ID.GetID() != matches[1] {
-		// ID mismatch
-		return nil, errors.New("id mismatch")
+	id, err := ParseID(matches[1])
+	if err != nil {
+		return nil, err
 	}
+	info.ID = id
 	// jump over the marker
 	raw = raw[len(matches[0]):]
+	if matches = regexSignature.FindStringSubmatch(raw); len(matches) == 2 {
+		info.Signature = matches[1]
+		raw = raw[:len(raw)-len(matches[0])]
+	}
 	if len(raw) <= 0 {
 		return &info, nil
 	}
@@ -64,6 +126,12 @@ func ParseInfo(raw string) (*Info, error) {
 	return &info, nil
 }
 
+// MetaErr returns the error that prevented the meta from being decrypted (e.g. a MetaKeyNotFoundError),
+// nil if the meta could be read
+func (i *Info) MetaErr() error {
+	return i.metaErr
+}
+
 // Build builds a info
 func (i *Info) Build() (string, error) {
 	var sb strings.Builder
@@ -77,7 +145,13 @@ func (i *Info) Build() (string, error) {
 		sb.Write(bytes)
 		sb.WriteString("--->")
 	}
+	if i.Signature != "" {
+		sb.WriteString(makeSignatureMarker(i.Signature))
+	}
 	sb.WriteRune('\n')
 	sb.WriteString(i.Body)
+	if len(i.History) > 0 {
+		sb.WriteString(buildHistory(i.History))
+	}
 	return sb.String(), nil
 }
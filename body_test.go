@@ -17,9 +17,13 @@ func TestParseInfo(t *testing.T) {
 		{fmt.Sprintf("%s\nHello World!", makeMagicMarker(ID("123"))), &Info{ID: ID("123"), Body: "Hello World!"}, ""},
 		{fmt.Sprintf("%s<!---[1,2,3]--->\nHello World!", makeMagicMarker(ID("123"))), &Info{ID: ID("123"), Meta: []interface{}{float64(1), float64(2), float64(3)}, Body: "Hello World!"}, ""},
 		{fmt.Sprintf("%s\r\n<!---Hello World--->", makeMagicMarker(ID("123"))), &Info{ID: ID("123"), Body: "<!---Hello World--->"}, ""},
+		{"<!---github-info-id-ÖÄL--->\n", &Info{ID: ID("ÖÄL")}, ""},
+		{fmt.Sprintf("%s\nHello World!", makeMagicMarker(ID("ci/lint"))), &Info{ID: ID("ci/lint"), Body: "Hello World!"}, ""},
+		{fmt.Sprintf("%s<!---[1,2,3]--->\nHello World!", makeMagicMarker(ID("build:linux_amd64"))), &Info{ID: ID("build:linux_amd64"), Meta: []interface{}{float64(1), float64(2), float64(3)}, Body: "Hello World!"}, ""},
 
 		{"Hello World", nil, "no marker found (invalid header)"},
-		{"<!---github-info-id-ÖÄL--->\n", nil, "no marker found (regex failure)"},
+		{"<!---github-info-id-ÖÄL.--->\n", nil, "no marker found (regex failure)"},
+		{"<!---github-info-id-cilint.aaaa--->\n", nil, "id mismatch"},
 		{"<!---github-info-id-123---><!Hello World>\n", nil, "no meta found (regex failure)"},
 		{"<!---github-info-id-123---><!---Hello World--->\n", nil, "invalid character 'H' looking for beginning of value"},
 	}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/Eun/github-comment/detect"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// batchOperation is one operation of a batch file
+type batchOperation struct {
+	// Target is an issue or pull request url or owner/repo#number, instead of Repo and Issue/PR
+	Target string `json:"target,omitempty"`
+	// Repo defaults to the --repo
+	Repo  string `json:"repo,omitempty"`
+	Issue int    `json:"issue,omitempty"`
+	PR    int    `json:"pr,omitempty"`
+	ID    string `json:"id,omitempty"`
+	// Op is one of post (update the comment with the id or post a new one), update, delete or set-meta
+	Op   string      `json:"op"`
+	Body string      `json:"body,omitempty"`
+	Meta interface{} `json:"meta,omitempty"`
+	// Template defaults to the template of the ids profile
+	Template string `json:"template,omitempty"`
+}
+
+// batchResult is written for every operation
+type batchResult struct {
+	// Index is the position of the operation in the file (starting with 1)
+	Index  int                   `json:"index"`
+	Op     string                `json:"op"`
+	Repo   string                `json:"repo,omitempty"`
+	Issue  int                   `json:"issue,omitempty"`
+	ID     string                `json:"id,omitempty"`
+	Result *githubcomment.Result `json:"result,omitempty"`
+	Error  string                `json:"error,omitempty"`
+	// ErrorClass is the class of the error (not_found, unauthorized, ...), see errorClass
+	ErrorClass string `json:"error_class,omitempty"`
+}
+
+// readBatch reads the operations from a jsonl file or, if isYAML is set, from a yaml list
+func readBatch(r io.Reader, isYAML bool) ([]batchOperation, error) {
+	if isYAML {
+		buf, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if err := yaml.Unmarshal(buf, &v); err != nil {
+			return nil, err
+		}
+		// yaml maps have interface{} keys, so take a trip through json to get the same types as for jsonl
+		buf, err = json.Marshal(normalizeYAML(v))
+		if err != nil {
+			return nil, err
+		}
+		var ops []batchOperation
+		if err := json.Unmarshal(buf, &ops); err != nil {
+			return nil, err
+		}
+		return ops, nil
+	}
+
+	var ops []batchOperation
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var op batchOperation
+		if err := json.Unmarshal(scanner.Bytes(), &op); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, scanner.Err()
+}
+
+// normalizeYAML converts the maps of yaml.v2 to map[string]interface{}
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(value)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = normalizeYAML(v[i])
+		}
+	}
+	return v
+}
+
+// runBatch runs the operations with at most concurrency operations at a time,
+// the results are written as jsonl in the order the operations finish.
+// It returns the exit code: 0 if all operations succeeded, 2 if some failed and
+// if all failed the exit code of their class (1 if they failed with different classes)
+func runBatch(ops []batchOperation, concurrency int, run func(op *batchOperation) (*batchResult, error), w io.Writer) int {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	enc := json.NewEncoder(w)
+	failed, code := 0, 0
+	indices := make(chan int)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				result, err := run(&ops[index])
+				if result == nil {
+					result = &batchResult{Op: ops[index].Op, ID: ops[index].ID}
+				}
+				result.Index = index + 1
+				classCode := 0
+				if err != nil {
+					result.Error = err.Error()
+					result.ErrorClass, classCode = errorClass(err)
+				}
+				mu.Lock()
+				if err != nil {
+					if failed == 0 {
+						code = classCode
+					} else if code != classCode {
+						code = 1
+					}
+					failed++
+				}
+				enc.Encode(result)
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range ops {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	switch {
+	case failed == 0:
+		return 0
+	case failed < len(ops):
+		return 2
+	}
+	return code
+}
+
+// runBatchOperation runs a single operation with the client of the comment
+func runBatchOperation(op *batchOperation) (*batchResult, error) {
+	result := batchResult{Op: op.Op, ID: op.ID}
+	gc := comment
+	issueID := op.Issue
+	if op.PR != 0 {
+		issueID = op.PR
+	}
+	var commentID int64
+	if op.Target != "" {
+		target, err := detect.ParseTarget(op.Target)
+		if err != nil {
+			return &result, err
+		}
+		// all operations share the client, so they cannot address another server
+		if host := apiHost(clientAPIURL()); target.Host != "" && !strings.EqualFold(target.Host, host) {
+			return &result, fmt.Errorf("target `%s' is on `%s', but the client uses `%s'", op.Target, target.Host, host)
+		}
+		gc.Owner, gc.Repository, issueID, commentID = target.Owner, target.Repository, target.Number, target.CommentID
+	} else if op.Repo != "" {
+		var err error
+		if gc.Owner, gc.Repository, err = parseOwnerAndRepo(op.Repo); err != nil {
+			return &result, fmt.Errorf("invalid repository `%s': %v", op.Repo, err)
+		}
+	}
+	if gc.Owner == "" {
+		return &result, fmt.Errorf("no repository specified")
+	}
+	result.Repo = gc.Owner + "/" + gc.Repository
+	result.Issue = issueID
+	if issueID == 0 {
+		return &result, fmt.Errorf("no issue or pull request specified")
+	}
+
+	id := githubcomment.ID(op.ID)
+	tmpl := op.Template
+	if p := cfg.IDs[op.ID]; p != nil {
+		gc.StickyBottom = p.Sticky
+		gc.KeepHistory = p.KeepHistory
+		if tmpl == "" {
+			tmpl = p.Template
+		}
+	}
+	text := op.Body
+	if tmpl != "" && (op.Op == "post" || op.Op == "update") {
+		var err error
+		if text, err = renderTemplate(op.ID, tmpl, text, op.Meta); err != nil {
+			return &result, err
+		}
+	}
+
+	var err error
+	if commentID != 0 {
+		// the comment is addressed directly without searching for the id
+		switch op.Op {
+		case "update":
+			result.Result, err = gc.UpdateIssueCommentByID(commentID, id, text, op.Meta)
+		case "delete":
+			result.Result, err = gc.DeleteIssueCommentByID(commentID)
+		case "set-meta":
+			result.Result, err = gc.SetIssueCommentMetaByID(commentID, id, op.Meta)
+		default:
+			err = fmt.Errorf("operation `%s' cannot be used with a comment target", op.Op)
+		}
+		return &result, err
+	}
+	switch op.Op {
+	case "post":
+		// like the post command, so a second post with the same id does not create a duplicate
+		result.Result, err = gc.PostOrUpdateIssueComment(issueID, id, text, op.Meta)
+	case "update":
+		result.Result, err = gc.UpdateIssueComment(issueID, id, text, op.Meta)
+	case "delete":
+		result.Result, err = gc.DeleteIssueComment(issueID, id)
+	case "set-meta":
+		result.Result, err = gc.SetIssueCommentMeta(issueID, id, op.Meta)
+	default:
+		err = fmt.Errorf("unknown operation `%s'", op.Op)
+	}
+	return &result, err
+}
+
+func batch() {
+	var r io.Reader = os.Stdin
+	if *batchFileArg != "-" {
+		f, err := os.Open(*batchFileArg)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		r = f
+	}
+	isYAML := strings.HasSuffix(*batchFileArg, ".yml") || strings.HasSuffix(*batchFileArg, ".yaml")
+	ops, err := readBatch(r, isYAML)
+	if err != nil {
+		fail(fmt.Errorf("invalid batch file: %w", err))
+	}
+
+	// the repository is only a default for the operations
+	if *repositoryFlag == "" {
+		detectTarget()
+	}
+	if *repositoryFlag != "" {
+		comment.Owner, comment.Repository, err = parseOwnerAndRepo(*repositoryFlag)
+		if err != nil {
+			fail(fmt.Errorf("invalid repository `%s': %w", *repositoryFlag, err))
+		}
+	}
+	rateLimit.Reserve = *batchReserve
+	initClient(*dryRunFlag)
+
+	os.Exit(runBatch(ops, *batchConcurrency, runBatchOperation, os.Stdout))
+}
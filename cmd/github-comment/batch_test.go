@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBatch(t *testing.T) {
+	expected := []batchOperation{
+		{Repo: "owner/repo", Issue: 1, ID: "build", Op: "update", Body: "Hello", Meta: map[string]interface{}{"ok": true}},
+		{Target: "owner/repo#2", ID: "build", Op: "delete"},
+	}
+
+	ops, err := readBatch(strings.NewReader(`{"repo":"owner/repo","issue":1,"id":"build","op":"update","body":"Hello","meta":{"ok":true}}
+
+{"target":"owner/repo#2","id":"build","op":"delete"}
+`), false)
+	require.NoError(t, err)
+	require.Equal(t, expected, ops)
+
+	ops, err = readBatch(strings.NewReader(`
+- repo: owner/repo
+  issue: 1
+  id: build
+  op: update
+  body: Hello
+  meta:
+    ok: true
+- target: owner/repo#2
+  id: build
+  op: delete
+`), true)
+	require.NoError(t, err)
+	require.Equal(t, expected, ops)
+
+	_, err = readBatch(strings.NewReader("{\"op\":\"post\"}\n{\n"), false)
+	require.EqualError(t, err, "line 2: unexpected end of JSON input")
+}
+
+func TestRunBatch(t *testing.T) {
+	ops := []batchOperation{{Op: "post", ID: "a"}, {Op: "post", ID: "b"}, {Op: "post", ID: "c"}}
+	var out bytes.Buffer
+	code := runBatch(ops, 2, func(op *batchOperation) (*batchResult, error) {
+		if op.ID == "b" {
+			return nil, errors.New("failed")
+		}
+		return &batchResult{Op: op.Op, ID: op.ID}, nil
+	}, &out)
+	require.Equal(t, 2, code)
+
+	results := map[string]batchResult{}
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var result batchResult
+		require.NoError(t, dec.Decode(&result))
+		results[result.ID] = result
+	}
+	require.Equal(t, batchResult{Index: 1, Op: "post", ID: "a"}, results["a"])
+	require.Equal(t, batchResult{Index: 2, Op: "post", ID: "b", Error: "failed", ErrorClass: "error"}, results["b"])
+	require.Equal(t, batchResult{Index: 3, Op: "post", ID: "c"}, results["c"])
+}
+
+func TestRunBatchExitCode(t *testing.T) {
+	ops := []batchOperation{{Op: "post", ID: "a"}, {Op: "post", ID: "b"}}
+	run := func(errs map[string]error) int {
+		return runBatch(ops, 2, func(op *batchOperation) (*batchResult, error) {
+			return nil, errs[op.ID]
+		}, ioutil.Discard)
+	}
+	require.Equal(t, 0, run(nil))
+	notFound := githubcomment.NotFoundError{Err: errors.New("not found")}
+	// all failed with the same class
+	require.Equal(t, 3, run(map[string]error{"a": notFound, "b": notFound}))
+	// all failed with different classes
+	require.Equal(t, 1, run(map[string]error{"a": notFound, "b": githubcomment.ForbiddenError{Err: errors.New("forbidden")}}))
+	require.Equal(t, 2, run(map[string]error{"a": notFound}))
+}
+
+func TestRunBatchOperationValidation(t *testing.T) {
+	_, err := runBatchOperation(&batchOperation{Repo: "owner/repo", ID: "a", Op: "post"})
+	require.EqualError(t, err, "no issue or pull request specified")
+
+	_, err = runBatchOperation(&batchOperation{Repo: "owner/repo", Issue: 1, ID: "a", Op: "rename"})
+	require.EqualError(t, err, "unknown operation `rename'")
+
+	_, err = runBatchOperation(&batchOperation{Target: "owner/repo1/repo2#1", Op: "post"})
+	require.EqualError(t, err, "invalid repository in target `owner/repo1/repo2#1'")
+
+	_, err = runBatchOperation(&batchOperation{Target: "https://github.example.com/owner/repo/pull/1", Op: "update"})
+	require.EqualError(t, err, "target `https://github.example.com/owner/repo/pull/1' is on `github.example.com', but the client uses `github.com'")
+
+	_, err = runBatchOperation(&batchOperation{Target: "https://github.com/owner/repo/issues/5#issuecomment-123", Op: "post"})
+	require.EqualError(t, err, "operation `post' cannot be used with a comment target")
+}
+
+func TestRunBatchOperationPostUpdates(t *testing.T) {
+	saved := comment
+	defer func() { comment = saved }()
+	comment = githubcomment.GithubComment{Client: newGithubStandIn(t), Context: context.Background(), Owner: "owner", Repository: "repo"}
+
+	first, err := runBatchOperation(&batchOperation{Issue: 1, ID: "release", Op: "post", Body: "v1"})
+	require.NoError(t, err)
+	require.Equal(t, githubcomment.ActionCreated, first.Result.Action)
+
+	// a second post with the same id updates the comment instead of creating a duplicate
+	second, err := runBatchOperation(&batchOperation{Issue: 1, ID: "release", Op: "post", Body: "v2"})
+	require.NoError(t, err)
+	require.Equal(t, githubcomment.ActionUpdated, second.Result.Action)
+	require.Equal(t, first.Result.CommentID, second.Result.CommentID)
+}
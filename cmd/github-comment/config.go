@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/alecthomas/kingpin"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// configFileNames are the names of the config file that are searched for
+var configFileNames = []string{".github-comment.yml", ".github-comment.yaml"}
+
+// config is the content of the config file
+type config struct {
+	Repo           string              `yaml:"repo,omitempty"`
+	APIURL         string              `yaml:"api-url,omitempty"`
+	TokenEnv       string              `yaml:"token-env,omitempty"`
+	TokenFile      string              `yaml:"token-file,omitempty"`
+	MetaFormat     string              `yaml:"meta-format,omitempty"`
+	TrustedAuthors []string            `yaml:"trusted-authors,omitempty"`
+	IDs            map[string]*profile `yaml:"ids,omitempty"`
+	// Commands are the slash commands of the webhook
+	Commands map[string]*slashCommand `yaml:"commands,omitempty"`
+}
+
+// profile holds the settings for a specific id
+type profile struct {
+	// Template is a text/template for the body, it gets the .Text, .Meta and .ID
+	Template     string `yaml:"template,omitempty"`
+	Sticky       bool   `yaml:"sticky,omitempty"`
+	HidePrevious bool   `yaml:"hide-previous,omitempty"`
+	KeepHistory  int    `yaml:"keep-history,omitempty"`
+	MetaFormat   string `yaml:"meta-format,omitempty"`
+}
+
+// cfg is the effective config (the config file merged with the flags)
+var cfg config
+
+// cfgPath is the path of the loaded config file, empty if there is none
+var cfgPath string
+
+// idProfile is the profile of the --id
+var idProfile profile
+
+// findConfigFile searches the config file in dir and its parents, it returns an empty string if there is none
+func findConfigFile(dir string) string {
+	for {
+		for _, name := range configFileNames {
+			path := filepath.Join(dir, name)
+			if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+				return path
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// parseConfig parses a config file, unknown keys are an error so typos do not go unnoticed
+func parseConfig(buf []byte) (*config, error) {
+	var c config
+	if err := yaml.UnmarshalStrict(buf, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// loadConfig loads the config file from --config or searches it upward from the working directory
+func loadConfig() (*config, string, error) {
+	path := *configFlag
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, "", err
+		}
+		if path = findConfigFile(cwd); path == "" {
+			return &config{}, "", nil
+		}
+	}
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read config: %v", err)
+	}
+	c, err := parseConfig(buf)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid config `%s': %v", path, err)
+	}
+	return c, path, nil
+}
+
+// flagsSetByUser returns the names of the flags that were specified on the command line
+func flagsSetByUser() map[string]bool {
+	set := map[string]bool{}
+	context, err := kingpin.CommandLine.ParseContext(os.Args[1:])
+	if err != nil {
+		return set
+	}
+	for _, element := range context.Elements {
+		if flag, ok := element.Clause.(*kingpin.FlagClause); ok {
+			set[flag.Model().Name] = true
+		}
+	}
+	return set
+}
+
+// applyConfig loads the config file and fills the flags that were not specified with its values,
+// afterwards cfg holds the effective settings
+func applyConfig() {
+	c, path, err := loadConfig()
+	if err != nil {
+		fail(err)
+	}
+	cfg = *c
+	cfgPath = path
+	if p := cfg.IDs[*idFlag]; p != nil {
+		idProfile = *p
+	}
+
+	set := flagsSetByUser()
+	if set["repo"] || set["target"] {
+		cfg.Repo = *repositoryFlag
+	} else if *repositoryFlag == "" {
+		*repositoryFlag = cfg.Repo
+	}
+	cfg.APIURL = stringSetting(set["api-url"], apiURLFlag, cfg.APIURL, "")
+	cfg.TokenEnv = stringSetting(set["token-env"], tokenEnvFlag, cfg.TokenEnv, "")
+	cfg.TokenFile = stringSetting(set["token-file"], tokenFileFlag, cfg.TokenFile, "")
+	if set["trusted-author"] {
+		cfg.TrustedAuthors = *trustedAuthors
+	} else {
+		*trustedAuthors = cfg.TrustedAuthors
+	}
+
+	metaFormat := cfg.MetaFormat
+	if idProfile.MetaFormat != "" {
+		metaFormat = idProfile.MetaFormat
+	}
+	for _, flag := range []*string{getMetaFormat, setMetaFormat, setMetaCmdFormat, diffMetaFormat} {
+		stringSetting(set["meta-format"], flag, metaFormat, "json")
+	}
+	if set["meta-format"] {
+		idProfile.MetaFormat = *setMetaFormat
+	}
+	if cfg.MetaFormat == "" {
+		cfg.MetaFormat = "json"
+	}
+
+	if set["hide-previous"] {
+		idProfile.HidePrevious = *hidePrevious
+	} else {
+		*hidePrevious = idProfile.HidePrevious
+	}
+	if set["sticky-bottom"] {
+		idProfile.Sticky = *stickyBottom
+	} else {
+		*stickyBottom = idProfile.Sticky
+	}
+	if set["keep-history"] {
+		idProfile.KeepHistory = *keepHistory
+	} else {
+		*keepHistory = idProfile.KeepHistory
+	}
+	if *idFlag != "" && cfg.IDs[*idFlag] != nil {
+		p := idProfile
+		cfg.IDs[*idFlag] = &p
+	}
+}
+
+// stringSetting sets the flag to the config value (or the fallback) if it was not specified and returns its value
+func stringSetting(set bool, flag *string, value, fallback string) string {
+	if !set {
+		*flag = value
+	}
+	if *flag == "" {
+		*flag = fallback
+	}
+	return *flag
+}
+
+// printConfig prints the effective settings
+func printConfig() {
+	if cfgPath != "" {
+		fmt.Fprintf(os.Stdout, "# %s\n", cfgPath)
+	}
+	yaml.NewEncoder(os.Stdout).Encode(cfg)
+	os.Exit(0)
+}
+
+// renderTemplate renders the template of a profile with the text and the meta
+func renderTemplate(id, tmpl, text string, meta interface{}) (string, error) {
+	return renderTemplateData(id, tmpl, map[string]interface{}{
+		"ID":   id,
+		"Text": text,
+		"Meta": meta,
+	})
+}
+
+// renderTemplateData renders a template of the config with the data
+func renderTemplateData(name, tmpl string, data map[string]interface{}) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid template for `%s': %v", name, err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("unable to render the template for `%s': %v", name, err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "a", "b")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	require.Equal(t, "", findConfigFile(sub))
+
+	path := filepath.Join(dir, ".github-comment.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte("repo: owner/repo\n"), 0644))
+	require.Equal(t, path, findConfigFile(sub))
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := parseConfig([]byte(`
+repo: owner/repo
+token-env: MY_TOKEN
+ids:
+  coverage:
+    template: "Coverage: {{.Text}}"
+    sticky: true
+`))
+	require.NoError(t, err)
+	require.Equal(t, "owner/repo", c.Repo)
+	require.Equal(t, "MY_TOKEN", c.TokenEnv)
+	require.Equal(t, &profile{Template: "Coverage: {{.Text}}", Sticky: true}, c.IDs["coverage"])
+
+	_, err = parseConfig([]byte("repository: owner/repo\n"))
+	require.Error(t, err)
+}
+
+func TestRenderTemplate(t *testing.T) {
+	text, err := renderTemplate("coverage", "{{.Text}} ({{.Meta.coverage}}%)", "Coverage", map[string]interface{}{"coverage": 90})
+	require.NoError(t, err)
+	require.Equal(t, "Coverage (90%)", text)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	githubcomment "github.com/Eun/github-comment"
+)
+
+const (
+	colorReset = "\x1b[0m"
+	colorBold  = "\x1b[1m"
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorCyan  = "\x1b[36m"
+)
+
+// colorize colors the lines of a unified diff
+func colorize(diff string) string {
+	if diff == "" {
+		return ""
+	}
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		var color string
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			color = colorBold
+		case strings.HasPrefix(line, "@@"):
+			color = colorCyan
+		case strings.HasPrefix(line, "+"):
+			color = colorGreen
+		case strings.HasPrefix(line, "-"):
+			color = colorRed
+		default:
+			continue
+		}
+		text := strings.TrimSuffix(line, "\n")
+		lines[i] = color + text + colorReset + line[len(text):]
+	}
+	return strings.Join(lines, "")
+}
+
+// useColor reports whether the diff should be colorized, auto colors if stdout is a terminal and NO_COLOR is not set
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// metaString formats the meta for the diff
+func metaString(meta interface{}) (string, error) {
+	if meta == nil {
+		return "", nil
+	}
+	buf, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(buf) + "\n", nil
+}
+
+// diffInfo returns the diff of the body and the meta of the current and the candidate info
+func diffInfo(current, candidate *githubcomment.Info) (string, error) {
+	currentMeta, err := metaString(current.Meta)
+	if err != nil {
+		return "", err
+	}
+	candidateMeta, err := metaString(candidate.Meta)
+	if err != nil {
+		return "", err
+	}
+	return unifiedDiff(current.Body, candidate.Body, "current", "candidate") +
+		unifiedDiff(currentMeta, candidateMeta, "current meta", "candidate meta"), nil
+}
+
+// currentInfo returns an empty info if the comment does not exist yet,
+// so the whole candidate is shown as an addition
+func currentInfo(info *githubcomment.Info, err error) (*githubcomment.Info, bool, error) {
+	var notFound githubcomment.IssueCommentNotFoundError
+	if errors.As(err, &notFound) {
+		return &githubcomment.Info{}, true, nil
+	}
+	return info, false, err
+}
+
+// diff prints the diff of the posted comment and the candidate,
+// it exits with 0 if they are identical, 1 if they differ (or the comment does not exist) and 2 on errors that have no exit code of their own
+func diff() {
+	fail := func(err error) {
+		printError(err)
+		if _, code := errorClass(err); code != exitError {
+			os.Exit(code)
+		}
+		os.Exit(2)
+	}
+
+	var buf []byte
+	var err error
+	if *diffFileArg == "" || *diffFileArg == "-" {
+		buf, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		buf, err = ioutil.ReadFile(*diffFileArg)
+	}
+	if err != nil {
+		fail(err)
+	}
+	meta, err := readMeta(*diffMetaFlag, *diffMetaFormat)
+	if err != nil {
+		fail(err)
+	}
+	candidate := &githubcomment.Info{Body: string(buf), Meta: meta}
+	// compare with what post would write
+	if idProfile.Template != "" {
+		if candidate.Body, err = renderTemplate(*idFlag, idProfile.Template, candidate.Body, meta); err != nil {
+			fail(err)
+		}
+	}
+
+	current, missing, err := currentInfo(fetchInfo())
+	if err != nil {
+		fail(err)
+	}
+	d, err := diffInfo(current, candidate)
+	if err != nil {
+		fail(err)
+	}
+	if d == "" && !missing {
+		os.Exit(0)
+	}
+	if useColor(*diffColor) {
+		d = colorize(d)
+	}
+	fmt.Fprint(os.Stdout, d)
+	os.Exit(1)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffInfo(t *testing.T) {
+	current := &githubcomment.Info{Body: "Hello World\n", Meta: map[string]interface{}{"coverage": 90}}
+
+	d, err := diffInfo(current, &githubcomment.Info{Body: "Hello World\n", Meta: map[string]interface{}{"coverage": 90}})
+	require.NoError(t, err)
+	require.Empty(t, d)
+
+	d, err = diffInfo(current, &githubcomment.Info{Body: "Hello Universe\n", Meta: map[string]interface{}{"coverage": 91}})
+	require.NoError(t, err)
+	require.Equal(t, "--- current\n+++ candidate\n@@ -1 +1 @@\n-Hello World\n+Hello Universe\n"+
+		"--- current meta\n+++ candidate meta\n@@ -1,3 +1,3 @@\n {\n-  \"coverage\": 90\n+  \"coverage\": 91\n }\n", d)
+}
+
+func TestDiffMissingComment(t *testing.T) {
+	current, missing, err := currentInfo(nil, githubcomment.IssueCommentNotFoundError{ID: "build"})
+	require.NoError(t, err)
+	require.True(t, missing)
+
+	d, err := diffInfo(current, &githubcomment.Info{Body: "Hello World\n", Meta: map[string]interface{}{"coverage": 90}})
+	require.NoError(t, err)
+	require.Equal(t, "--- current\n+++ candidate\n@@ -0,0 +1 @@\n+Hello World\n"+
+		"--- current meta\n+++ candidate meta\n@@ -0,0 +1,3 @@\n+{\n+  \"coverage\": 90\n+}\n", d)
+
+	// other errors are still reported
+	_, _, err = currentInfo(nil, githubcomment.NotFoundError{Err: errors.New("repository not found")})
+	require.EqualError(t, err, "repository not found")
+}
+
+func TestColorize(t *testing.T) {
+	require.Equal(t, "\x1b[1m--- a\x1b[0m\n\x1b[1m+++ b\x1b[0m\n\x1b[36m@@ -1 +1 @@\x1b[0m\n\x1b[31m-x\x1b[0m\n\x1b[32m+y\x1b[0m\n z\n",
+		colorize("--- a\n+++ b\n@@ -1 +1 @@\n-x\n+y\n z\n"))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// describePlan describes what a dry run result would have done
+func describePlan(result *githubcomment.Result) string {
+	switch result.Action {
+	case githubcomment.ActionCreated:
+		return "would create a new comment"
+	case githubcomment.ActionUpdated:
+		if result.CommentID == 0 {
+			return "would edit the issue body"
+		}
+		return fmt.Sprintf("would edit comment %d", result.CommentID)
+	case githubcomment.ActionReposted:
+		return fmt.Sprintf("would delete comment %d and post it again", result.CommentID)
+	case githubcomment.ActionDeleted:
+		return fmt.Sprintf("would delete comment %d", result.CommentID)
+	}
+	return "no-op, the comment is unchanged"
+}
+
+// printPlan prints the planned action, the body that would be written and a diff against the current body
+func printPlan(w io.Writer, result *githubcomment.Result) {
+	fmt.Fprintf(w, "%s (id `%s')\n", describePlan(result), string(result.ID))
+	for _, commentID := range result.Hidden {
+		fmt.Fprintf(w, "would hide comment %d\n", commentID)
+	}
+	if result.Body != "" {
+		fmt.Fprintf(w, "--- body ---\n%s\n", result.Body)
+	}
+	if result.Action != githubcomment.ActionUnchanged {
+		fmt.Fprintf(w, "--- diff ---\n%s", unifiedDiff(result.PreviousBody, result.Body, "current", "planned"))
+	}
+}
+
+// unifiedDiff returns the unified diff of a and b, it is empty if they are equal
+func unifiedDiff(a, b, fromFile, toFile string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(a),
+		B:        splitLines(b),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	return diff
+}
+
+// splitLines splits s into lines that all end with a new line
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] = last + "\n"
+	}
+	return lines
+}
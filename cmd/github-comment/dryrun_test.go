@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintPlan(t *testing.T) {
+	var buf bytes.Buffer
+	printPlan(&buf, &githubcomment.Result{
+		ID:           "build",
+		CommentID:    12,
+		Action:       githubcomment.ActionUpdated,
+		DryRun:       true,
+		Body:         "<!---github-info-id-build--->\nHello Universe",
+		PreviousBody: "<!---github-info-id-build--->\nHello World",
+	})
+	require.Equal(t, "would edit comment 12 (id `build')\n"+
+		"--- body ---\n<!---github-info-id-build--->\nHello Universe\n"+
+		"--- diff ---\n--- current\n+++ planned\n@@ -1,2 +1,2 @@\n <!---github-info-id-build--->\n-Hello World\n+Hello Universe\n", buf.String())
+
+	buf.Reset()
+	printPlan(&buf, &githubcomment.Result{ID: "build", Action: githubcomment.ActionUnchanged, DryRun: true, Body: "body"})
+	require.Equal(t, "no-op, the comment is unchanged (id `build')\n--- body ---\nbody\n", buf.String())
+}
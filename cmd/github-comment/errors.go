@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/google/go-github/github"
+)
+
+// exit codes, 2 is used by batch for partial failures and by diff for other errors
+const (
+	exitError        = 1
+	exitNotFound     = 3
+	exitUnauthorized = 4
+	exitForbidden    = 5
+	exitRateLimited  = 6
+	exitValidation   = 7
+	exitConflict     = 8
+)
+
+// errorClass returns the name and the exit code of the class of err
+func errorClass(err error) (string, int) {
+	switch {
+	case errors.Is(err, githubcomment.NotFoundError{}):
+		return "not_found", exitNotFound
+	case errors.Is(err, githubcomment.UnauthorizedError{}), errors.Is(err, errNoToken):
+		return "unauthorized", exitUnauthorized
+	case errors.Is(err, githubcomment.ForbiddenError{}):
+		return "forbidden", exitForbidden
+	case errors.Is(err, githubcomment.RateLimitedError{}):
+		return "rate_limited", exitRateLimited
+	case errors.Is(err, githubcomment.ValidationError{}):
+		return "validation", exitValidation
+	case errors.Is(err, githubcomment.ConflictError{}):
+		return "conflict", exitConflict
+	}
+	return "error", exitError
+}
+
+// errorOutput is printed for --error-format json
+type errorOutput struct {
+	Error    string `json:"error"`
+	Class    string `json:"class"`
+	ExitCode int    `json:"exit_code"`
+	// Status is the http status of the github response
+	Status int `json:"status,omitempty"`
+	// Reset is the unix time the rate limit resets
+	Reset int64 `json:"reset,omitempty"`
+}
+
+// encodeError writes err in the format of --error-format
+func encodeError(w io.Writer, err error, format string) {
+	if format != "json" {
+		fmt.Fprintf(w, "%v\n", err.Error())
+		return
+	}
+	out := errorOutput{Error: err.Error()}
+	out.Class, out.ExitCode = errorClass(err)
+	var errorResponse *github.ErrorResponse
+	var rateLimitError *github.RateLimitError
+	var abuseRateLimitError *github.AbuseRateLimitError
+	switch {
+	case errors.As(err, &errorResponse) && errorResponse.Response != nil:
+		out.Status = errorResponse.Response.StatusCode
+	case errors.As(err, &rateLimitError) && rateLimitError.Response != nil:
+		out.Status = rateLimitError.Response.StatusCode
+	case errors.As(err, &abuseRateLimitError) && abuseRateLimitError.Response != nil:
+		out.Status = abuseRateLimitError.Response.StatusCode
+	}
+	var rateLimited githubcomment.RateLimitedError
+	if errors.As(err, &rateLimited) && !rateLimited.Reset.IsZero() {
+		out.Reset = rateLimited.Reset.Unix()
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// printError prints err to stderr
+func printError(err error) {
+	encodeError(os.Stderr, err, *errorFormatFlag)
+}
+
+// fail prints err and exits with the code of its class
+func fail(err error) {
+	printError(err)
+	_, code := errorClass(err)
+	os.Exit(code)
+}
+
+// exitFailed exits with the code of the class of err, 0 if err is nil
+func exitFailed(err error) {
+	if err != nil {
+		_, code := errorClass(err)
+		os.Exit(code)
+	}
+	os.Exit(0)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		Err   error
+		Class string
+		Code  int
+	}{
+		{errors.New("failed"), "error", exitError},
+		{githubcomment.IssueCommentNotFoundError{ID: "build"}, "not_found", exitNotFound},
+		{fmt.Errorf("unable to find the pull request: %w", githubcomment.NotFoundError{Err: errors.New("404")}), "not_found", exitNotFound},
+		{githubcomment.UnauthorizedError{Err: errors.New("401")}, "unauthorized", exitUnauthorized},
+		{errNoToken, "unauthorized", exitUnauthorized},
+		{githubcomment.UntrustedAuthorError{CommentID: 1, Author: "mallory"}, "forbidden", exitForbidden},
+		{githubcomment.RateLimitedError{Err: errors.New("403")}, "rate_limited", exitRateLimited},
+		{githubcomment.IDMustBeSpecifiedError{}, "validation", exitValidation},
+		{githubcomment.IDCollisionError{ID: "build", Marker: "x"}, "conflict", exitConflict},
+	}
+	for _, test := range tests {
+		class, code := errorClass(test.Err)
+		require.Equal(t, test.Class, class, test.Err.Error())
+		require.Equal(t, test.Code, code, test.Err.Error())
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	var buf bytes.Buffer
+	err := githubcomment.RateLimitedError{
+		Err: &github.RateLimitError{
+			Response: &http.Response{StatusCode: http.StatusForbidden, Request: httptest.NewRequest(http.MethodGet, "https://api.github.com/user", nil)},
+			Message:  "API rate limit exceeded",
+		},
+		Reset: time.Unix(1700000000, 0),
+	}
+	encodeError(&buf, err, "json")
+	require.JSONEq(t, `{"error":"`+err.Error()+`","class":"rate_limited","exit_code":6,"status":403,"reset":1700000000}`, buf.String())
+
+	buf.Reset()
+	encodeError(&buf, errors.New("failed"), "text")
+	require.Equal(t, "failed\n", buf.String())
+}
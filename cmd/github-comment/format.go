@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	githubcomment "github.com/Eun/github-comment"
+)
+
+// htmlTags are the html tags that github keeps in comments, other text in angle brackets
+// (like `a<b and c>d') is not a tag
+const htmlTags = `h[1-8]|br|b|i|strong|em|a|pre|code|img|tt|div|ins|del|sup|sub|p|ol|ul|table|thead|tbody|tfoot|` +
+	`blockquote|dl|dt|dd|kbd|q|samp|var|hr|ruby|rt|rp|li|tr|td|th|s|strike|summary|details|caption|` +
+	`figure|figcaption|abbr|bdo|cite|dfn|mark|small|span|time|wbr|picture|source|input`
+
+var (
+	fencePattern        = regexp.MustCompile("^\\s{0,3}(```|~~~)")
+	commentPattern      = regexp.MustCompile(`(?s)<!--.*?-->`)
+	autolinkPattern     = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	tagPattern          = regexp.MustCompile(`(?i)</?(?:` + htmlTags + `)(?:\s+[a-z][\w:-]*\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>` + "`" + `]+)|\s+(?:open|checked|disabled|hidden))*\s*/?>`)
+	headingPattern      = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(\s+#+)?\s*$`)
+	quotePattern        = regexp.MustCompile(`^\s{0,3}>\s?`)
+	rulePattern         = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$|^\s*(\*\s*){3,}$|^\s*(_\s*){3,}$|^\s*(-\s*){3,}$`)
+	listPattern         = regexp.MustCompile(`^(\s*)[*+]\s+`)
+	codeSpanPattern     = regexp.MustCompile("`+([^`]+)`+")
+	imagePattern        = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	linkPattern         = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	strongPattern       = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	emphasisPattern     = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:[^*_]*?\S)?)[*_]`)
+	strikePattern       = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	blankLinesPattern   = regexp.MustCompile(`\n{3,}`)
+	trailingWSPattern   = regexp.MustCompile(`[ \t]+\n`)
+	leadingLinesPattern = regexp.MustCompile(`^\n+`)
+)
+
+// stripMarkdown turns markdown into plain text for logs,
+// code blocks are kept as they are, links are written as `text (url)'
+func stripMarkdown(s string) string {
+	s = commentPattern.ReplaceAllString(strings.Replace(s, "\r\n", "\n", -1), "")
+	var sb strings.Builder
+	inFence := ""
+	for _, line := range strings.Split(s, "\n") {
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			switch {
+			case inFence == "":
+				inFence = m[1]
+				continue
+			case inFence == m[1]:
+				inFence = ""
+				continue
+			}
+		}
+		if inFence == "" {
+			if rulePattern.MatchString(line) {
+				continue
+			}
+			line = stripMarkdownLine(line)
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	s = trailingWSPattern.ReplaceAllString(sb.String(), "\n")
+	s = blankLinesPattern.ReplaceAllString(s, "\n\n")
+	s = leadingLinesPattern.ReplaceAllString(s, "")
+	return strings.TrimRight(s, "\n") + "\n"
+}
+
+// stripMarkdownLine strips the markdown of a line outside of code blocks
+func stripMarkdownLine(line string) string {
+	line = quotePattern.ReplaceAllString(line, "")
+	line = headingPattern.ReplaceAllString(line, "$1")
+	line = listPattern.ReplaceAllString(line, "$1- ")
+
+	// code spans are kept literally, only the text around them is stripped
+	var sb strings.Builder
+	last := 0
+	for _, m := range codeSpanPattern.FindAllStringSubmatchIndex(line, -1) {
+		sb.WriteString(stripInline(line[last:m[0]]))
+		sb.WriteString(line[m[2]:m[3]])
+		last = m[1]
+	}
+	sb.WriteString(stripInline(line[last:]))
+	return sb.String()
+}
+
+// stripInline strips the inline markdown and html of text
+func stripInline(s string) string {
+	s = autolinkPattern.ReplaceAllString(s, "$1")
+	s = tagPattern.ReplaceAllString(s, "")
+	s = imagePattern.ReplaceAllString(s, "$1")
+	s = linkPattern.ReplaceAllStringFunc(s, func(link string) string {
+		m := linkPattern.FindStringSubmatch(link)
+		if m[1] == m[2] {
+			return m[1]
+		}
+		return m[1] + " (" + m[2] + ")"
+	})
+	s = strongPattern.ReplaceAllString(s, "$2")
+	s = emphasisPattern.ReplaceAllString(s, "$1$2")
+	s = strikePattern.ReplaceAllString(s, "$1")
+	return html.UnescapeString(s)
+}
+
+// formatBody formats the markdown body for the get --format
+func formatBody(body, format string) (string, error) {
+	switch format {
+	case "html":
+		return comment.RenderMarkdown(body)
+	case "text":
+		return stripMarkdown(body), nil
+	}
+	return body, nil
+}
+
+// writeInfo writes the comment in the format, json includes the metadata of the comment
+func writeInfo(w io.Writer, info *githubcomment.Info, format string) error {
+	if format == "json" {
+		return json.NewEncoder(w).Encode(info)
+	}
+	body, err := formatBody(info.Body, format)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(w, body)
+	return err
+}
+
+// writeHistory writes the previous bodies (newest first) in the format
+func writeHistory(w io.Writer, history []githubcomment.HistoryEntry, format string) error {
+	if format == "json" {
+		if history == nil {
+			history = []githubcomment.HistoryEntry{}
+		}
+		return json.NewEncoder(w).Encode(history)
+	}
+	for _, entry := range history {
+		body, err := formatBody(entry.Body, format)
+		if err != nil {
+			return err
+		}
+		if format == "html" {
+			fmt.Fprintf(w, "<h2>%s</h2>\n%s\n", html.EscapeString(entry.Title), body)
+			continue
+		}
+		fmt.Fprintf(w, "=== %s ===\n%s\n\n", entry.Title, body)
+	}
+	return nil
+}
+
+// writeOutput writes buf to the file, or to stdout if file is empty or -
+func writeOutput(file string, buf []byte) error {
+	if file == "" || file == "-" {
+		_, err := os.Stdout.Write(buf)
+		return err
+	}
+	if err := ioutil.WriteFile(file, buf, 0644); err != nil {
+		return fmt.Errorf("unable to write the output: %w", err)
+	}
+	return nil
+}
+
+// getText prints the comment (or its history) in the get --format
+func getText() {
+	format := strings.ToLower(*getFormatFlag)
+	var buf bytes.Buffer
+	var err error
+	if *getHistory {
+		err = writeHistory(&buf, get().History, format)
+	} else {
+		err = writeInfo(&buf, get(), format)
+	}
+	if err != nil {
+		fail(err)
+	}
+	if err = writeOutput(*getOutputFile, buf.Bytes()); err != nil {
+		fail(err)
+	}
+	os.Exit(0)
+}
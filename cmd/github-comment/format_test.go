@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripMarkdown(t *testing.T) {
+	input := "<!-- marker -->\n" +
+		"## Coverage report ##\n" +
+		"\n" +
+		"**Total**: *90%* of `src/**/*.go`, see [the details](https://example.com/report \"title\") ~~or not~~\n" +
+		"\n" +
+		"* item_one\n" +
+		"+ item two\n" +
+		"- [x] done\n" +
+		"\n" +
+		"> quoted &amp; escaped\n" +
+		"\n" +
+		"---\n" +
+		"\n" +
+		"\n" +
+		"| file | coverage |\n" +
+		"|------|---------:|\n" +
+		"| a.go | 90%      |\n" +
+		"<details><summary>Log</summary>\n" +
+		"\n" +
+		"```go\n" +
+		"**not bold** in code\n" +
+		"```\n" +
+		"</details>\n" +
+		"![badge](https://example.com/badge.svg) <https://example.com>\n"
+
+	expected := "Coverage report\n" +
+		"\n" +
+		"Total: 90% of src/**/*.go, see the details (https://example.com/report) or not\n" +
+		"\n" +
+		"- item_one\n" +
+		"- item two\n" +
+		"- [x] done\n" +
+		"\n" +
+		"quoted & escaped\n" +
+		"\n" +
+		"| file | coverage |\n" +
+		"| a.go | 90%      |\n" +
+		"Log\n" +
+		"\n" +
+		"**not bold** in code\n" +
+		"\n" +
+		"badge https://example.com\n"
+	require.Equal(t, expected, stripMarkdown(input))
+}
+
+func TestStripInlineTags(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Output string
+	}{
+		{"a<b and c>d", "a<b and c>d"},
+		{"x <y> z", "x <y> z"},
+		{"<b>bold</b> <br/> <img src=\"a.png\" width=100>", "bold  "},
+		{"<details open><summary>Log</summary></details>", "Log"},
+		{"<a href='https://example.com'>link</a>", "link"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.Output, stripInline(test.Input), test.Input)
+	}
+}
+
+func TestWriteHistory(t *testing.T) {
+	history := []githubcomment.HistoryEntry{{Title: "Previous", Body: "**old**"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeHistory(&buf, history, "text"))
+	require.Equal(t, "=== Previous ===\nold\n\n\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeHistory(&buf, nil, "json"))
+	require.Equal(t, "[]\n", buf.String())
+}
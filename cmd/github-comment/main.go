@@ -1,15 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
 	"os"
 	"strings"
 
 	githubcomment "github.com/Eun/github-comment"
+	"github.com/Eun/github-comment/detect"
 	"github.com/alecthomas/kingpin"
 	"github.com/google/go-github/github"
 	"golang.org/x/oauth2"
@@ -17,20 +22,79 @@ import (
 )
 
 var (
-	idFlag         = kingpin.Flag("id", "id for this comment").String()
-	repositoryFlag = kingpin.Flag("repo", "repository").PlaceHolder("owner/repo").Required().String()
-	issueFlag      = kingpin.Flag("issue", "issue id").PlaceHolder("1234").Int()
-	prFlag         = kingpin.Flag("pr", "pull request id").PlaceHolder("1234").Int()
-
-	getCmd = kingpin.Command("get", "get the text of a posted comment")
+	idFlag          = kingpin.Flag("id", "id for this comment").String()
+	configFlag      = kingpin.Flag("config", "config file (searched upward from the working directory if omitted)").PlaceHolder(".github-comment.yml").String()
+	apiURLFlag      = kingpin.Flag("api-url", "url of the github api, for github enterprise").PlaceHolder("https://github.example.com/api/v3/").String()
+	tokenEnvFlag    = kingpin.Flag("token-env", "environment variable that holds the token").PlaceHolder("GITHUB_TOKEN").String()
+	tokenFileFlag   = kingpin.Flag("token-file", "file that holds the token").PlaceHolder("token.txt").String()
+	showTokenFlag   = kingpin.Flag("show-token-source", "print where the token is taken from (never the token itself) and exit").Bool()
+	repositoryFlag  = kingpin.Flag("repo", "repository or clone url (detected from the ci environment or the git remote if omitted)").PlaceHolder("owner/repo").String()
+	targetFlag      = kingpin.Flag("target", "issue, pull request or comment url, or owner/repo#number").PlaceHolder("url").String()
+	remoteFlag      = kingpin.Flag("remote", "git remote to detect the repository from").Default("origin").String()
+	issueFlag       = kingpin.Flag("issue", "issue id").PlaceHolder("1234").Int()
+	prFlag          = kingpin.Flag("pr", "pull request id (detected from the ci environment if omitted)").PlaceHolder("1234").Int()
+	branchFlag      = kingpin.Flag("branch", "find the open pull request by its head branch").PlaceHolder("branch").String()
+	shaFlag         = kingpin.Flag("sha", "find the pull request by a commit").PlaceHolder("sha").String()
+	prPolicyFlag    = kingpin.Flag("pr-policy", "what to do if --branch or --sha match no or several pull requests").PlaceHolder("fail|skip|all").Default("fail").Enum("fail", "skip", "all")
+	metaSchemaFlag  = kingpin.Flag("meta-schema", "json schema to validate the meta against").PlaceHolder("schema.json").String()
+	metaKeyFile     = kingpin.Flag("meta-key-file", "file with keys (id:base64key, one per line) to encrypt the meta, the first key is used for encryption").PlaceHolder("keys.txt").String()
+	signingKeyFile  = kingpin.Flag("signing-key-file", "file with the secret to sign comments (defaults to the environment GITHUB_COMMENT_SIGNING_KEY)").PlaceHolder("secret.txt").String()
+	requireSigFlag  = kingpin.Flag("require-signature", "ignore comments without a valid signature").Bool()
+	migrateLegacy   = kingpin.Flag("migrate-legacy-markers", "find and migrate comments created by earlier versions (their marker equals the id without the characters other than letters, numbers and -)").Bool()
+	dryRunFlag      = kingpin.Flag("dry-run", "only read and print what would be written").Bool()
+	errorFormatFlag = kingpin.Flag("error-format", "format of the errors printed to stderr").PlaceHolder("text|json").Default("text").Enum("text", "json")
+	debugFlag       = kingpin.Flag("debug", "log the http requests and responses to stderr, tokens are redacted").Envar("GITHUB_COMMENT_DEBUG").Bool()
+	debugBodyLimit  = kingpin.Flag("debug-body-limit", "truncate the bodies in the debug log to N bytes, 0 logs complete bodies").PlaceHolder("N").Default("4096").Int()
+	trustedAuthors  = kingpin.Flag("trusted-author", "only consider comments of this author, @me is the authenticated user (repeatable)").PlaceHolder("login").Strings()
+
+	getCmd        = kingpin.Command("get", "get the text of a posted comment")
+	getFormatFlag = getCmd.Flag("format", "output format, json includes the metadata of the comment, html is rendered by github and text strips the markdown").PlaceHolder("raw|json|html|text").Default("raw").Enum("raw", "json", "html", "text")
+	getHistory    = getCmd.Flag("history", "list the previous bodies instead of the current one").Bool()
+	getOutputFile = getCmd.Flag("output", "write to the file instead of stdout").Short('o').PlaceHolder("file").String()
 
 	getMetaCmd    = kingpin.Command("get-meta", "get the meta of a posted comment")
-	getMetaFormat = getMetaCmd.Flag("meta-format", "format for the meta").PlaceHolder("json|yml").Default("json").String()
+	getMetaFormat = getMetaCmd.Flag("meta-format", "format for the meta").PlaceHolder("json|yml").String()
 
 	postOrUpdateCmd = kingpin.Command("post", "post or update a new comment").Default()
-	setMetaFormat   = postOrUpdateCmd.Flag("meta-format", "format for the meta").PlaceHolder("json|yml").Default("json").String()
+	setMetaFormat   = postOrUpdateCmd.Flag("meta-format", "format for the meta").PlaceHolder("json|yml").String()
 	setMetaFlag     = postOrUpdateCmd.Flag("meta", "meta to set").String()
 	setTextFlag     = postOrUpdateCmd.Arg("text", "text to post").String()
+	postOutputFlag  = postOrUpdateCmd.Flag("output", "output format for the result, nothing is printed if omitted").PlaceHolder("text|json").String()
+	hidePrevious    = postOrUpdateCmd.Flag("hide-previous", "always post a new comment and hide the previous comments with the same id as outdated").Bool()
+	keepHistory     = postOrUpdateCmd.Flag("keep-history", "keep the last N bodies in a collapsible section").PlaceHolder("N").Int()
+	stickyBottom    = postOrUpdateCmd.Flag("sticky-bottom", "post the comment again if other comments were posted after it").Bool()
+
+	setMetaCmd        = kingpin.Command("set-meta", "replace the meta of a posted comment")
+	setMetaCmdFormat  = setMetaCmd.Flag("meta-format", "format for the meta").PlaceHolder("json|yml").String()
+	setMetaCmdOutput  = setMetaCmd.Flag("output", "output format for the result").PlaceHolder("text|json").Default("text").String()
+	setMetaCmdMetaArg = setMetaCmd.Arg("meta", "meta to set").String()
+
+	hideCmd = kingpin.Command("hide", "hide all comments with the id as outdated")
+
+	diffCmd        = kingpin.Command("diff", "compare the posted comment with a candidate body, exits with 1 if they differ")
+	diffFileArg    = diffCmd.Arg("file", "file with the candidate body (defaults to stdin)").String()
+	diffMetaFlag   = diffCmd.Flag("meta", "candidate meta").String()
+	diffMetaFormat = diffCmd.Flag("meta-format", "format for the meta").PlaceHolder("json|yml").String()
+	diffColor      = diffCmd.Flag("color", "colorize the diff").PlaceHolder("auto|always|never").Default("auto").Enum("auto", "always", "never")
+
+	deleteCmd    = kingpin.Command("delete", "delete the comment with the id")
+	deleteOutput = deleteCmd.Flag("output", "output format for the result").PlaceHolder("text|json").Default("text").String()
+
+	batchCmd         = kingpin.Command("batch", "run the operations of a jsonl (or yaml) file")
+	batchFileArg     = batchCmd.Arg("file", "file with the operations, - for stdin").Required().String()
+	batchConcurrency = batchCmd.Flag("concurrency", "number of operations that run at the same time").Default("4").Int()
+	batchReserve     = batchCmd.Flag("rate-limit-reserve", "wait for the rate limit reset when only N requests are left").PlaceHolder("N").Int()
+
+	serveCmd       = kingpin.Command("serve", "serve the comment operations as a rest api")
+	serveListen    = serveCmd.Flag("listen", "address to listen on").Default("127.0.0.1:8080").String()
+	serveTokenFile = serveCmd.Flag("auth-token-file", "file with the bearer tokens of the callers, one per line (defaults to the environment GITHUB_COMMENT_SERVE_TOKEN)").PlaceHolder("tokens.txt").String()
+
+	webhookCmd        = kingpin.Command("webhook", "receive issue_comment webhooks and apply the slash commands of the config")
+	webhookListen     = webhookCmd.Flag("listen", "address to listen on").Default("127.0.0.1:8080").String()
+	webhookSecretFile = webhookCmd.Flag("secret-file", "file with the secret of the webhook (defaults to the environment GITHUB_COMMENT_WEBHOOK_SECRET)").PlaceHolder("secret.txt").String()
+
+	configCmd      = kingpin.Command("config", "show the configuration")
+	configPrintCmd = configCmd.Command("print", "print the effective settings (the config file merged with the flags)")
 )
 
 var version string
@@ -43,7 +107,21 @@ func main() {
 	kingpin.Version(fmt.Sprintf("%s %s %s", version, commit, date))
 	cmd := kingpin.Parse()
 	sanitizeFlags()
-	initComments()
+	applyConfig()
+	if *showTokenFlag {
+		showTokenSource()
+	}
+	switch cmd {
+	case configPrintCmd.FullCommand():
+		printConfig()
+	case batchCmd.FullCommand():
+		batch()
+	case serveCmd.FullCommand():
+		serve()
+	case webhookCmd.FullCommand():
+		receiveWebhooks()
+	}
+	initComments(cmd == getCmd.FullCommand() || cmd == getMetaCmd.FullCommand() || cmd == diffCmd.FullCommand() || *dryRunFlag)
 	switch cmd {
 	case getCmd.FullCommand():
 		getText()
@@ -51,6 +129,14 @@ func main() {
 		getMeta()
 	case postOrUpdateCmd.FullCommand():
 		postOrUpdate()
+	case setMetaCmd.FullCommand():
+		setMeta()
+	case hideCmd.FullCommand():
+		hide()
+	case deleteCmd.FullCommand():
+		deleteComment()
+	case diffCmd.FullCommand():
+		diff()
 	}
 }
 
@@ -61,11 +147,46 @@ func sanitizeFlags() {
 		idFlag = &nullString
 	}
 
+	if configFlag == nil {
+		var nullString string
+		configFlag = &nullString
+	}
+
+	if apiURLFlag == nil {
+		var nullString string
+		apiURLFlag = &nullString
+	}
+
+	if tokenEnvFlag == nil {
+		var nullString string
+		tokenEnvFlag = &nullString
+	}
+
+	if tokenFileFlag == nil {
+		var nullString string
+		tokenFileFlag = &nullString
+	}
+
+	if showTokenFlag == nil {
+		var no bool
+		showTokenFlag = &no
+	}
+
 	if repositoryFlag == nil {
 		var nullString string
 		repositoryFlag = &nullString
 	}
 
+	if targetFlag == nil {
+		var nullString string
+		targetFlag = &nullString
+	}
+
+	if remoteFlag == nil {
+		var nullString string
+		remoteFlag = &nullString
+	}
+
 	if issueFlag == nil {
 		var zero int
 		issueFlag = &zero
@@ -76,6 +197,87 @@ func sanitizeFlags() {
 		prFlag = &zero
 	}
 
+	if branchFlag == nil {
+		var nullString string
+		branchFlag = &nullString
+	}
+
+	if shaFlag == nil {
+		var nullString string
+		shaFlag = &nullString
+	}
+
+	if prPolicyFlag == nil {
+		var nullString string
+		prPolicyFlag = &nullString
+	}
+
+	if metaSchemaFlag == nil {
+		var nullString string
+		metaSchemaFlag = &nullString
+	}
+
+	if metaKeyFile == nil {
+		var nullString string
+		metaKeyFile = &nullString
+	}
+
+	if signingKeyFile == nil {
+		var nullString string
+		signingKeyFile = &nullString
+	}
+
+	if requireSigFlag == nil {
+		var no bool
+		requireSigFlag = &no
+	}
+
+	if migrateLegacy == nil {
+		var no bool
+		migrateLegacy = &no
+	}
+
+	if dryRunFlag == nil {
+		var no bool
+		dryRunFlag = &no
+	}
+
+	if errorFormatFlag == nil {
+		text := "text"
+		errorFormatFlag = &text
+	}
+
+	if debugFlag == nil {
+		var no bool
+		debugFlag = &no
+	}
+
+	if debugBodyLimit == nil {
+		var zero int
+		debugBodyLimit = &zero
+	}
+
+	if trustedAuthors == nil {
+		var empty []string
+		trustedAuthors = &empty
+	}
+
+	// get command
+	if getFormatFlag == nil {
+		var nullString string
+		getFormatFlag = &nullString
+	}
+
+	if getHistory == nil {
+		var no bool
+		getHistory = &no
+	}
+
+	if getOutputFile == nil {
+		var nullString string
+		getOutputFile = &nullString
+	}
+
 	// get meta command
 	if getMetaFormat == nil {
 		var nullString string
@@ -97,114 +299,520 @@ func sanitizeFlags() {
 		var nullString string
 		setTextFlag = &nullString
 	}
+
+	if postOutputFlag == nil {
+		var nullString string
+		postOutputFlag = &nullString
+	}
+
+	if hidePrevious == nil {
+		var no bool
+		hidePrevious = &no
+	}
+
+	if keepHistory == nil {
+		var zero int
+		keepHistory = &zero
+	}
+
+	if stickyBottom == nil {
+		var no bool
+		stickyBottom = &no
+	}
+
+	// set meta command
+	if setMetaCmdFormat == nil {
+		var nullString string
+		setMetaCmdFormat = &nullString
+	}
+
+	if setMetaCmdOutput == nil {
+		var nullString string
+		setMetaCmdOutput = &nullString
+	}
+
+	if setMetaCmdMetaArg == nil {
+		var nullString string
+		setMetaCmdMetaArg = &nullString
+	}
+
+	// diff command
+	if diffFileArg == nil {
+		var nullString string
+		diffFileArg = &nullString
+	}
+
+	if diffMetaFlag == nil {
+		var nullString string
+		diffMetaFlag = &nullString
+	}
+
+	if diffMetaFormat == nil {
+		var nullString string
+		diffMetaFormat = &nullString
+	}
+
+	if diffColor == nil {
+		var nullString string
+		diffColor = &nullString
+	}
+
+	// delete command
+	if deleteOutput == nil {
+		var nullString string
+		deleteOutput = &nullString
+	}
+
+	// batch command
+	if batchFileArg == nil {
+		var nullString string
+		batchFileArg = &nullString
+	}
+
+	if batchConcurrency == nil {
+		var one = 1
+		batchConcurrency = &one
+	}
+
+	if batchReserve == nil {
+		var zero int
+		batchReserve = &zero
+	}
+
+	// serve command
+	if serveListen == nil {
+		var nullString string
+		serveListen = &nullString
+	}
+
+	if serveTokenFile == nil {
+		var nullString string
+		serveTokenFile = &nullString
+	}
+
+	// webhook command
+	if webhookListen == nil {
+		var nullString string
+		webhookListen = &nullString
+	}
+
+	if webhookSecretFile == nil {
+		var nullString string
+		webhookSecretFile = &nullString
+	}
 }
 
-func initComments() {
+// initComments prepares the comment for the target of the flags,
+// readOnly commands fall back to an anonymous client if there is no token
+func initComments(readOnly bool) {
+	applyTarget()
+	if *repositoryFlag == "" || (*issueFlag == 0 && *prFlag == 0) {
+		detectTarget()
+	}
+
 	var err error
 	comment.Owner, comment.Repository, err = parseOwnerAndRepo(*repositoryFlag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "invalid repository `%s': %v\n", *repositoryFlag, err.Error())
-		os.Exit(1)
+		fail(fmt.Errorf("invalid repository `%s': %w", *repositoryFlag, err))
 	}
 
-	if *issueFlag == 0 && *prFlag == 0 {
-		fmt.Fprint(os.Stderr, "either --issue or --pr must be specified\n")
-		os.Exit(1)
+	if *issueFlag == 0 && *prFlag == 0 && *branchFlag == "" && *shaFlag == "" && targetCommentID == 0 {
+		fail(errors.New("either --issue, --pr, --branch or --sha must be specified"))
+	}
+
+	initClient(readOnly)
+
+	if targetCommentID == 0 {
+		issueNumbers = resolveIssueNumbers()
 	}
+}
 
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		fmt.Fprint(os.Stderr, "environment GITHUB_TOKEN is not set\n")
-		os.Exit(1)
+// useAnonymousClient decides what happens with the error of resolveToken:
+// read only commands fall back to an anonymous client if no token was found (unless @me has to be resolved),
+// all other errors (including errNoToken for commands that write) are returned
+func useAnonymousClient(tokenErr error, readOnly bool, trustedAuthors []string) (bool, error) {
+	if tokenErr != errNoToken || !readOnly {
+		return false, tokenErr
+	}
+	for _, author := range trustedAuthors {
+		if author == githubcomment.Me {
+			return false, fmt.Errorf("--trusted-author %s needs a token", githubcomment.Me)
+		}
 	}
+	return true, nil
+}
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	tc := oauth2.NewClient(oauth2.NoContext, ts)
+// initClient creates the client and applies the flags that are shared by all commands,
+// if readOnly is set and no token is found an anonymous client is used
+func initClient(readOnly bool) {
+	apiURL := clientAPIURL()
+	if *debugFlag {
+		rateLimit.Base = &githubcomment.DebugTransport{
+			Base:    http.DefaultTransport,
+			Logger:  log.New(os.Stderr, "debug: ", log.LstdFlags|log.Lmicroseconds),
+			MaxBody: *debugBodyLimit,
+		}
+	}
+	tc := &http.Client{Transport: rateLimit}
+	token, _, err := resolveToken(detect.OSEnvironment(), apiHost(apiURL), cfg.TokenEnv, cfg.TokenFile)
+	anonymous, err := useAnonymousClient(err, readOnly, *trustedAuthors)
+	switch {
+	case err != nil:
+		fail(err)
+	case anonymous:
+		fmt.Fprint(os.Stderr, "warning: no token found, reading anonymously which only works for public repositories and is limited to 60 requests per hour\n")
+	default:
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+		tc = oauth2.NewClient(context.WithValue(context.Background(), oauth2.HTTPClient, tc), ts)
+	}
 
-	comment.Client = github.NewClient(tc)
+	if apiURL == "" {
+		comment.Client = github.NewClient(tc)
+	} else {
+		comment.Client, err = github.NewEnterpriseClient(apiURL, apiURL, tc)
+		if err != nil {
+			fail(fmt.Errorf("invalid api url `%s': %w", apiURL, err))
+		}
+	}
 	comment.Context = context.Background()
+	comment.DryRun = *dryRunFlag
+	comment.MigrateLegacyMarkers = *migrateLegacy
+
+	if *metaSchemaFlag != "" {
+		buf, err := ioutil.ReadFile(*metaSchemaFlag)
+		if err != nil {
+			fail(fmt.Errorf("unable to read meta schema: %w", err))
+		}
+		comment.MetaSchema, err = githubcomment.ParseSchema(buf)
+		if err != nil {
+			fail(err)
+		}
+	}
+
+	comment.MetaKeys, err = readMetaKeys()
+	if err != nil {
+		fail(err)
+	}
+
+	comment.SigningKey = []byte(os.Getenv("GITHUB_COMMENT_SIGNING_KEY"))
+	if *signingKeyFile != "" {
+		comment.SigningKey, err = ioutil.ReadFile(*signingKeyFile)
+		if err != nil {
+			fail(fmt.Errorf("unable to read signing key file: %w", err))
+		}
+		comment.SigningKey = bytes.TrimSpace(comment.SigningKey)
+	}
+	if *requireSigFlag && len(comment.SigningKey) == 0 {
+		fail(errors.New("--require-signature needs a signing key"))
+	}
+	comment.SkipUnverified = *requireSigFlag
+	comment.TrustedAuthors = *trustedAuthors
+	// resolve @me once, batch and serve copy the comment for every operation
+	if err := comment.ResolveTrustedAuthors(); err != nil {
+		fail(err)
+	}
 }
 
-func parseOwnerAndRepo(s string) (owner, repo string, err error) {
-	p := strings.SplitN(s, "/", 2)
-	if len(p) == 2 {
-		return p[0], p[1], nil
+// readMetaKeys reads the keys from the environment GITHUB_COMMENT_META_KEY and the --meta-key-file
+func readMetaKeys() ([]githubcomment.MetaKey, error) {
+	keys, err := githubcomment.ParseMetaKeys(os.Getenv("GITHUB_COMMENT_META_KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GITHUB_COMMENT_META_KEY: %v", err)
+	}
+	if *metaKeyFile == "" {
+		return keys, nil
+	}
+	buf, err := ioutil.ReadFile(*metaKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read meta key file: %v", err)
+	}
+	fileKeys, err := githubcomment.ParseMetaKeys(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("invalid meta key file: %v", err)
 	}
-	return "", "", errors.New("unable to parse repository")
+	return append(keys, fileKeys...), nil
 }
 
-func postOrUpdate() {
-	var id int
-	if *issueFlag > 0 {
-		id = *issueFlag
-	} else {
-		id = *prFlag
+// detectTarget fills the missing repository and pull request from the ci environment or the git remote
+func detectTarget() {
+	target, err := detect.Detect(detect.OSEnvironment())
+	if err == detect.ErrNotDetected && *repositoryFlag == "" {
+		var cwd string
+		if cwd, err = os.Getwd(); err == nil {
+			target, err = detect.GitRemote{Dir: cwd, Remote: *remoteFlag}.Detect(detect.OSEnvironment())
+			if err == nil && target == nil {
+				err = detect.ErrNotDetected
+			}
+		}
+	}
+	if err != nil {
+		if err != detect.ErrNotDetected {
+			fmt.Fprintf(os.Stderr, "unable to detect the repository: %v\n", err.Error())
+		}
+		return
+	}
+	applyDetectedTarget(target)
+}
+
+// applyDetectedTarget fills the missing repository and pull request with the detected target,
+// the pull request is not used if --branch or --sha select one
+func applyDetectedTarget(target *detect.Target) {
+	repository := target.Owner + "/" + target.Repository
+	if *repositoryFlag == "" {
+		*repositoryFlag = repository
 	}
+	// only use the detected pull request if it belongs to the repository
+	if *issueFlag == 0 && *prFlag == 0 && *branchFlag == "" && *shaFlag == "" && strings.EqualFold(*repositoryFlag, repository) {
+		*prFlag = target.Number
+	}
+}
 
+func parseOwnerAndRepo(s string) (owner, repo string, err error) {
+	// clone urls like https://github.com/owner/repo.git or git@github.com:owner/repo.git
+	if strings.Contains(s, ":") {
+		return detect.ParseRepositoryURL(s)
+	}
+	p := strings.Split(s, "/")
+	if len(p) != 2 {
+		return "", "", errors.New("unable to parse repository")
+	}
+	if !detect.ValidOwner(p[0]) {
+		return "", "", fmt.Errorf("invalid owner `%s'", p[0])
+	}
+	if !detect.ValidRepository(p[1]) {
+		return "", "", fmt.Errorf("invalid repository name `%s'", p[1])
+	}
+	return p[0], p[1], nil
+}
+
+func postOrUpdate() {
 	if *setTextFlag == "" {
 		var sb strings.Builder
 		_, err := io.Copy(&sb, os.Stdin)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Unable to read from stdin: %v\n", err.Error())
-			os.Exit(1)
+			fail(fmt.Errorf("Unable to read from stdin: %w", err))
 		}
 		t := sb.String()
 		setTextFlag = &t
 	}
-	meta, err := readMetaFromFlags()
+	meta, err := readMeta(*setMetaFlag, *setMetaFormat)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err.Error())
-		os.Exit(1)
+		fail(err)
 	}
 
-	if err = comment.PostOrUpdateIssueComment(id, githubcomment.ID(*idFlag), *setTextFlag, meta); err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err.Error())
-		os.Exit(1)
+	if idProfile.Template != "" {
+		text, err := renderTemplate(*idFlag, idProfile.Template, *setTextFlag, meta)
+		if err != nil {
+			fail(err)
+		}
+		setTextFlag = &text
 	}
-	os.Exit(0)
+
+	comment.KeepHistory = *keepHistory
+	comment.StickyBottom = *stickyBottom
+
+	if targetCommentID != 0 {
+		if *hidePrevious || *stickyBottom {
+			fail(errors.New("--hide-previous and --sticky-bottom cannot be used with a comment target"))
+		}
+		result, err := comment.UpdateIssueCommentByID(targetCommentID, githubcomment.ID(*idFlag), *setTextFlag, meta)
+		if err != nil {
+			fail(err)
+		}
+		printResult(result, *postOutputFlag)
+		os.Exit(0)
+	}
+
+	var failed error
+	for _, id := range issueNumbers {
+		var result *githubcomment.Result
+		if *hidePrevious {
+			result, err = comment.PostAndHideIssueComment(id, githubcomment.ID(*idFlag), *setTextFlag, meta)
+		} else {
+			result, err = comment.PostOrUpdateIssueComment(id, githubcomment.ID(*idFlag), *setTextFlag, meta)
+		}
+		if err != nil {
+			printError(err)
+			failed = err
+			continue
+		}
+		printResult(result, *postOutputFlag)
+	}
+	exitFailed(failed)
 }
 
-func get() *githubcomment.Info {
-	var id int
-	if *issueFlag > 0 {
-		id = *issueFlag
-	} else {
-		id = *prFlag
+func setMeta() {
+	if *setMetaCmdMetaArg == "" {
+		var sb strings.Builder
+		_, err := io.Copy(&sb, os.Stdin)
+		if err != nil {
+			fail(fmt.Errorf("Unable to read from stdin: %w", err))
+		}
+		t := sb.String()
+		setMetaCmdMetaArg = &t
+	}
+	meta, err := readMeta(*setMetaCmdMetaArg, *setMetaCmdFormat)
+	if err != nil {
+		fail(err)
+	}
+
+	if targetCommentID != 0 {
+		result, err := comment.SetIssueCommentMetaByID(targetCommentID, githubcomment.ID(*idFlag), meta)
+		if err != nil {
+			fail(err)
+		}
+		printResult(result, *setMetaCmdOutput)
+		os.Exit(0)
+	}
+
+	var failed error
+	for _, id := range issueNumbers {
+		result, err := comment.SetIssueCommentMeta(id, githubcomment.ID(*idFlag), meta)
+		if err != nil {
+			printError(err)
+			failed = err
+			continue
+		}
+		printResult(result, *setMetaCmdOutput)
+	}
+	exitFailed(failed)
+}
+
+func hide() {
+	verb := "hidden"
+	if *dryRunFlag {
+		verb = "would hide"
+	}
+	if targetCommentID != 0 {
+		if err := comment.HideIssueCommentByID(targetCommentID); err != nil {
+			fail(err)
+		}
+		fmt.Fprintf(os.Stdout, "%s %d\n", verb, targetCommentID)
+		os.Exit(0)
+	}
+
+	var failed error
+	for _, id := range issueNumbers {
+		hidden, err := comment.HideIssueComments(id, githubcomment.ID(*idFlag))
+		if err != nil {
+			printError(err)
+			failed = err
+		}
+		for _, commentID := range hidden {
+			fmt.Fprintf(os.Stdout, "%s %d\n", verb, commentID)
+		}
+	}
+	exitFailed(failed)
+}
+
+func deleteComment() {
+	if targetCommentID != 0 {
+		result, err := comment.DeleteIssueCommentByID(targetCommentID)
+		if err != nil {
+			fail(err)
+		}
+		printResult(result, *deleteOutput)
+		os.Exit(0)
+	}
+
+	var failed error
+	for _, id := range issueNumbers {
+		result, err := comment.DeleteIssueComment(id, githubcomment.ID(*idFlag))
+		if err != nil {
+			printError(err)
+			failed = err
+			continue
+		}
+		printResult(result, *deleteOutput)
 	}
+	exitFailed(failed)
+}
+
+func printResult(result *githubcomment.Result, format string) {
+	writeResult(os.Stdout, result, format)
+}
 
-	info, err := comment.GetIssueComment(id, githubcomment.ID(*idFlag))
+// writeResult writes the result in the format, an empty format only writes the plan of a dry run
+func writeResult(w io.Writer, result *githubcomment.Result, format string) {
+	switch strings.ToLower(format) {
+	case "json":
+		json.NewEncoder(w).Encode(result)
+	default:
+		if result.DryRun {
+			printPlan(w, result)
+			return
+		}
+		if format == "" {
+			return
+		}
+		fmt.Fprintf(w, "%s %s %s\n", result.Action, string(result.ID), result.HTMLURL)
+		for _, commentID := range result.Hidden {
+			fmt.Fprintf(w, "hidden %d\n", commentID)
+		}
+	}
+}
+
+func get() *githubcomment.Info {
+	info, err := fetchInfo()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%v\n", err.Error())
-		os.Exit(1)
+		fail(err)
 	}
 	return info
 }
 
-func getText() {
-	fmt.Fprint(os.Stdout, get().Body)
-	os.Exit(0)
+// fetchInfo gets the comment of the target and warns if its signature could not be verified
+func fetchInfo() (*githubcomment.Info, error) {
+	var info *githubcomment.Info
+	var err error
+	if targetCommentID != 0 {
+		info, err = comment.GetIssueCommentByID(targetCommentID)
+	} else {
+		if len(issueNumbers) != 1 {
+			return nil, errors.New("several pull requests match, use --pr to select one")
+		}
+		info, err = comment.GetIssueComment(issueNumbers[0], githubcomment.ID(*idFlag))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(comment.SigningKey) > 0 && !info.Verified {
+		fmt.Fprintf(os.Stderr, "warning: the signature of the comment `%s' could not be verified\n", string(info.ID))
+	}
+	switch {
+	case info.MetaErr() != nil:
+		fmt.Fprintf(os.Stderr, "warning: the meta of the comment `%s' could not be decrypted: %s\n", string(info.ID), info.MetaError)
+	case info.MetaError != "":
+		fmt.Fprintf(os.Stderr, "warning: the meta of the comment `%s' does not match the schema: %s\n", string(info.ID), info.MetaError)
+	}
+	return info, nil
 }
 
 func getMeta() {
+	info := get()
+	if err := info.MetaErr(); err != nil {
+		fail(err)
+	}
 	switch strings.ToLower(*getMetaFormat) {
 	case "yml", "yaml":
-		yaml.NewEncoder(os.Stdout).Encode(get().Meta)
+		yaml.NewEncoder(os.Stdout).Encode(info.Meta)
 	default:
-		json.NewEncoder(os.Stdout).Encode(get().Meta)
+		json.NewEncoder(os.Stdout).Encode(info.Meta)
 	}
 
 	os.Exit(0)
 }
 
-func readMetaFromFlags() (v interface{}, err error) {
-	if *setMetaFlag != "" {
-		switch strings.ToLower(*setMetaFormat) {
+func readMeta(s, format string) (v interface{}, err error) {
+	if s != "" {
+		switch strings.ToLower(format) {
 		case "yml", "yaml":
-			err = yaml.Unmarshal([]byte(*setMetaFlag), &v)
+			err = yaml.Unmarshal([]byte(s), &v)
+			// yaml maps have interface{} keys which cannot be encoded as json
+			v = normalizeYAML(v)
 		default:
-			err = json.Unmarshal([]byte(*setMetaFlag), &v)
+			err = json.Unmarshal([]byte(s), &v)
 		}
 	}
 	return v, err
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/Eun/github-comment/detect"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,7 +20,12 @@ func TestParseOwnerAndRepo(t *testing.T) {
 		{"", "", "", errors.New("unable to parse repository")},
 		{"bob", "", "", errors.New("unable to parse repository")},
 		{"bob/repo", "bob", "repo", nil},
-		{"bob/repo1/repo2", "bob", "repo1/repo2", nil},
+		{"bob/repo1/repo2", "", "", errors.New("unable to parse repository")},
+		{"-bob/repo", "", "", errors.New("invalid owner `-bob'")},
+		{"bob/re po", "", "", errors.New("invalid repository name `re po'")},
+		{"https://github.com/bob/repo.git", "bob", "repo", nil},
+		{"git@github.com:bob/repo.git", "bob", "repo", nil},
+		{"ssh://git@github.com/bob/repo", "bob", "repo", nil},
 	}
 
 	for _, test := range tests {
@@ -27,3 +35,92 @@ func TestParseOwnerAndRepo(t *testing.T) {
 		require.Equal(t, test.Error, err)
 	}
 }
+
+func TestReadMeta(t *testing.T) {
+	v, err := readMeta("coverage: 90\njobs:\n  - name: lint\n", "yml")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"coverage": 90,
+		"jobs":     []interface{}{map[string]interface{}{"name": "lint"}},
+	}, v)
+}
+
+func TestApplyDetectedTarget(t *testing.T) {
+	defer func(repo string, issue, pr int, branch, sha string) {
+		*repositoryFlag, *issueFlag, *prFlag, *branchFlag, *shaFlag = repo, issue, pr, branch, sha
+	}(*repositoryFlag, *issueFlag, *prFlag, *branchFlag, *shaFlag)
+	target := &detect.Target{Owner: "owner", Repository: "repo", Number: 2}
+
+	*repositoryFlag, *issueFlag, *prFlag, *branchFlag, *shaFlag = "", 0, 0, "", ""
+	applyDetectedTarget(target)
+	require.Equal(t, "owner/repo", *repositoryFlag)
+	require.Equal(t, 2, *prFlag)
+
+	// an explicit --branch or --sha takes precedence over the pull request of the ci event
+	*repositoryFlag, *prFlag, *branchFlag = "", 0, "feature"
+	applyDetectedTarget(target)
+	require.Equal(t, "owner/repo", *repositoryFlag)
+	require.Equal(t, 0, *prFlag)
+
+	*repositoryFlag, *prFlag, *branchFlag, *shaFlag = "", 0, "", "abc"
+	applyDetectedTarget(target)
+	require.Equal(t, 0, *prFlag)
+
+	// the pull request of another repository is not used
+	*repositoryFlag, *prFlag, *shaFlag = "owner/other", 0, ""
+	applyDetectedTarget(target)
+	require.Equal(t, 0, *prFlag)
+}
+
+func TestWriteResult(t *testing.T) {
+	result := &githubcomment.Result{
+		ID:        "build",
+		CommentID: 1,
+		HTMLURL:   "https://github.com/owner/repo/issues/1#issuecomment-1",
+		Action:    githubcomment.ActionCreated,
+		Hidden:    []int64{2},
+	}
+
+	// post is silent unless --output is given
+	var buf bytes.Buffer
+	writeResult(&buf, result, "")
+	require.Empty(t, buf.String())
+
+	writeResult(&buf, result, "text")
+	require.Equal(t, "created build https://github.com/owner/repo/issues/1#issuecomment-1\nhidden 2\n", buf.String())
+
+	buf.Reset()
+	writeResult(&buf, result, "json")
+	require.JSONEq(t, `{"id":"build","comment_id":1,"html_url":"https://github.com/owner/repo/issues/1#issuecomment-1","action":"created","info":null,"hidden":[2]}`, buf.String())
+}
+
+func TestUseAnonymousClient(t *testing.T) {
+	tests := []struct {
+		Name      string
+		TokenErr  error
+		ReadOnly  bool
+		Authors   []string
+		Anonymous bool
+		Error     string
+		ExitCode  int
+	}{
+		{Name: "token", TokenErr: nil, ReadOnly: false},
+		{Name: "read without token", TokenErr: errNoToken, ReadOnly: true, Anonymous: true},
+		{Name: "write without token", TokenErr: errNoToken, ReadOnly: false, Error: errNoToken.Error(), ExitCode: exitUnauthorized},
+		{Name: "@me without token", TokenErr: errNoToken, ReadOnly: true, Authors: []string{githubcomment.Me}, Error: "--trusted-author @me needs a token", ExitCode: exitError},
+		{Name: "unreadable token file", TokenErr: errors.New("token file `x' is empty"), ReadOnly: true, Error: "token file `x' is empty", ExitCode: exitError},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			anonymous, err := useAnonymousClient(test.TokenErr, test.ReadOnly, test.Authors)
+			require.Equal(t, test.Anonymous, anonymous)
+			if test.Error == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, test.Error)
+			_, code := errorClass(err)
+			require.Equal(t, test.ExitCode, code)
+		})
+	}
+}
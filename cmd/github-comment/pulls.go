@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// issueNumbers are the issues (or pull requests) the command operates on
+var issueNumbers []int
+
+// resolveIssueNumbers returns the issue numbers of the flags,
+// --branch and --sha are resolved to pull requests and handled according to the --pr-policy
+func resolveIssueNumbers() []int {
+	switch {
+	case *issueFlag > 0:
+		return []int{*issueFlag}
+	case *prFlag > 0:
+		return []int{*prFlag}
+	}
+
+	var numbers []int
+	var err error
+	var source string
+	if *branchFlag != "" {
+		source = fmt.Sprintf("branch `%s'", *branchFlag)
+		numbers, err = comment.FindPullRequestsByBranch(*branchFlag)
+	} else {
+		source = fmt.Sprintf("commit `%s'", *shaFlag)
+		numbers, err = comment.FindPullRequestsByCommit(*shaFlag)
+	}
+	if err != nil {
+		fail(fmt.Errorf("unable to find the pull request for the %s: %w", source, err))
+	}
+	if len(numbers) == 1 {
+		return numbers
+	}
+
+	problem := fmt.Sprintf("%d pull requests match the %s", len(numbers), source)
+	switch *prPolicyFlag {
+	case "skip":
+		fmt.Fprintf(os.Stderr, "%s, skipping\n", problem)
+		os.Exit(0)
+	case "all":
+		if len(numbers) == 0 {
+			fmt.Fprintf(os.Stderr, "%s, nothing to do\n", problem)
+			os.Exit(0)
+		}
+		return numbers
+	}
+	fail(errors.New(problem))
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimit is the transport of the client, all requests share its budget
+var rateLimit = &rateLimitTransport{Base: http.DefaultTransport}
+
+// rateLimitTransport tracks the rate limit that github reports and makes requests wait
+// until the limit is reset when the remaining budget is used up,
+// so concurrent operations do not run into errors
+type rateLimitTransport struct {
+	Base http.RoundTripper
+	// Reserve is the number of requests that are left for other tools
+	Reserve int
+
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	reset     time.Time
+	now       func() time.Time
+	sleep     func(time.Duration)
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.take()
+	res, err := t.Base.RoundTrip(req)
+	if err == nil {
+		t.update(res.Header)
+	}
+	return res, err
+}
+
+// take waits until the budget allows another request and takes it
+func (t *rateLimitTransport) take() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now, sleep := time.Now, time.Sleep
+	if t.now != nil {
+		now, sleep = t.now, t.sleep
+	}
+	if !t.known {
+		return
+	}
+	if t.remaining <= t.Reserve && now().Before(t.reset) {
+		// keep the lock while sleeping so every other request waits as well
+		sleep(t.reset.Sub(now()))
+		t.known = false
+		return
+	}
+	t.remaining--
+}
+
+// update reads the budget from the headers of a response
+func (t *rateLimitTransport) update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.known = true
+	t.remaining = remaining
+	t.reset = time.Unix(reset, 0)
+}
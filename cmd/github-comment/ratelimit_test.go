@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (fn roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var slept time.Duration
+	remaining := 2
+	transport := &rateLimitTransport{
+		Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			header := http.Header{}
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			header.Set("X-RateLimit-Reset", "1060")
+			remaining--
+			return &http.Response{StatusCode: http.StatusOK, Header: header}, nil
+		}),
+		Reserve: 1,
+		now:     func() time.Time { return now },
+		sleep:   func(d time.Duration) { slept += d },
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/", nil)
+
+	// the first request learns the budget, the second one uses it
+	_, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Zero(t, slept)
+
+	// the budget is down to the reserve, so the next request waits for the reset
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, slept)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/Eun/github-comment/detect"
+)
+
+// server exposes the comment operations as a rest api:
+//
+//	GET    /repos/{owner}/{repo}/issues/{number}/comments       lists the comments with a marker
+//	GET    /repos/{owner}/{repo}/issues/{number}/comments/{id}  gets a comment
+//	PUT    /repos/{owner}/{repo}/issues/{number}/comments/{id}  posts or updates a comment ({"body": "...", "meta": ...})
+//	DELETE /repos/{owner}/{repo}/issues/{number}/comments/{id}  deletes a comment
+type server struct {
+	comment githubcomment.GithubComment
+	// tokens are the bearer tokens that callers must send
+	tokens []string
+}
+
+// putRequest is the body of a PUT request
+type putRequest struct {
+	Body string      `json:"body"`
+	Meta interface{} `json:"meta"`
+}
+
+// statusRecorder remembers the status code of a response for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs every request with its status and duration
+func logRequests(logger *log.Logger, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(rec, r)
+		logger.Printf("%s %s %s %d %s", r.RemoteAddr, r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+		return
+	}
+
+	// /repos/{owner}/{repo}/issues/{number}/comments[/{id}], the id may contain slashes
+	p := strings.SplitN(strings.TrimPrefix(r.URL.EscapedPath(), "/repos/"), "/", 6)
+	if !strings.HasPrefix(r.URL.Path, "/repos/") || len(p) < 5 || p[2] != "issues" || p[4] != "comments" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if !detect.ValidOwner(p[0]) || !detect.ValidRepository(p[1]) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid repository `%s/%s'", p[0], p[1]))
+		return
+	}
+	number, err := strconv.Atoi(p[3])
+	if err != nil || number <= 0 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid issue number `%s'", p[3]))
+		return
+	}
+	var id githubcomment.ID
+	if len(p) == 6 {
+		unescaped, err := url.PathUnescape(p[5])
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid id `%s'", p[5]))
+			return
+		}
+		id = githubcomment.ID(unescaped)
+	}
+
+	gc := s.comment
+	gc.Owner = p[0]
+	gc.Repository = p[1]
+	gc.Context = r.Context()
+
+	if id == "" {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		infos, err := gc.ListIssueComments(number)
+		if infos == nil {
+			infos = []*githubcomment.Info{}
+		}
+		writeResponse(w, http.StatusOK, infos, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		info, err := gc.GetIssueComment(number, id)
+		writeResponse(w, http.StatusOK, info, err)
+	case http.MethodPut:
+		var req putRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+			return
+		}
+		result, err := gc.UpdateIssueComment(number, id, req.Body, req.Meta)
+		status := http.StatusOK
+		if result != nil && result.Action == githubcomment.ActionCreated {
+			status = http.StatusCreated
+		}
+		writeResponse(w, status, result, err)
+	case http.MethodDelete:
+		result, err := gc.DeleteIssueComment(number, id)
+		writeResponse(w, http.StatusOK, result, err)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// authorized reports whether the request carries one of the bearer tokens
+func (s *server) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	token := []byte(strings.TrimPrefix(header, prefix))
+	authorized := false
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare(token, []byte(t)) == 1 {
+			authorized = true
+		}
+	}
+	return authorized
+}
+
+// writeResponse writes v as json or, if err is not nil, the error with a matching status
+func writeResponse(w http.ResponseWriter, status int, v interface{}, err error) {
+	if err != nil {
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// errorStatus maps the class of an error of the library (or of github) to a http status
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, githubcomment.NotFoundError{}):
+		return http.StatusNotFound
+	case errors.Is(err, githubcomment.ConflictError{}):
+		return http.StatusConflict
+	case errors.Is(err, githubcomment.ValidationError{}):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, githubcomment.ForbiddenError{}):
+		return http.StatusForbidden
+	case errors.Is(err, githubcomment.RateLimitedError{}):
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadGateway
+}
+
+// readServeTokens reads the bearer tokens from the --auth-token-file or the environment GITHUB_COMMENT_SERVE_TOKEN
+func readServeTokens() ([]string, error) {
+	s := os.Getenv("GITHUB_COMMENT_SERVE_TOKEN")
+	if *serveTokenFile != "" {
+		buf, err := ioutil.ReadFile(*serveTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read auth token file: %v", err)
+		}
+		s = string(buf)
+	}
+	var tokens []string
+	for _, token := range strings.Split(s, "\n") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("serve needs a token for the callers, set GITHUB_COMMENT_SERVE_TOKEN or pass --auth-token-file")
+	}
+	return tokens, nil
+}
+
+func serve() {
+	tokens, err := readServeTokens()
+	if err != nil {
+		fail(err)
+	}
+	initClient(false)
+
+	listenAndServe(*serveListen, &server{
+		comment: comment,
+		tokens:  tokens,
+	})
+}
+
+// listenAndServe serves the handler (logging all requests) until SIGINT or SIGTERM is received
+// and waits for running requests before it exits
+func listenAndServe(addr string, handler http.Handler) {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: logRequests(logger, handler),
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		logger.Printf("shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Printf("shutdown: %v", err)
+		}
+		close(stopped)
+	}()
+
+	logger.Printf("listening on %s", addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		fail(err)
+	}
+	<-stopped
+	os.Exit(0)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+// newGithubStandIn returns a client for a github stand-in that knows issue 1 of owner/repo without comments
+func newGithubStandIn(t *testing.T) *github.Client {
+	var comments []*github.IssueComment
+	gh := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/owner/repo/issues/1":
+			json.NewEncoder(w).Encode(&github.Issue{Number: github.Int(1), Body: github.String("")})
+		case r.URL.Path == "/repos/owner/repo/issues/1/comments" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(comments)
+		case r.URL.Path == "/repos/owner/repo/issues/comments/100" && r.Method == http.MethodPatch:
+			json.NewDecoder(r.Body).Decode(comments[0])
+			json.NewEncoder(w).Encode(comments[0])
+		case r.URL.Path == "/repos/owner/repo/issues/1/comments" && r.Method == http.MethodPost:
+			var comment github.IssueComment
+			json.NewDecoder(r.Body).Decode(&comment)
+			comment.ID = github.Int64(int64(100 + len(comments)))
+			comments = append(comments, &comment)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(&comment)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(gh.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(gh.URL + "/")
+	return client
+}
+
+// newTestServer returns a server backed by a github stand-in
+func newTestServer(t *testing.T) *httptest.Server {
+	s := httptest.NewServer(&server{
+		comment: githubcomment.GithubComment{Client: newGithubStandIn(t), Context: context.Background()},
+		tokens:  []string{"secret"},
+	})
+	t.Cleanup(s.Close)
+	return s
+}
+
+func doRequest(t *testing.T, method, url, token, body string) (int, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	buf, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(buf)
+}
+
+func TestServe(t *testing.T) {
+	s := newTestServer(t)
+	commentURL := s.URL + "/repos/owner/repo/issues/1/comments/ci%2Flint"
+
+	status, _ := doRequest(t, http.MethodGet, commentURL, "", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = doRequest(t, http.MethodGet, commentURL, "wrong", "")
+	require.Equal(t, http.StatusUnauthorized, status)
+
+	status, body := doRequest(t, http.MethodGet, commentURL, "secret", "")
+	require.Equal(t, http.StatusNotFound, status, body)
+
+	status, body = doRequest(t, http.MethodPut, commentURL, "secret", `{"body": "Hello World", "meta": {"ok": true}}`)
+	require.Equal(t, http.StatusCreated, status, body)
+
+	status, body = doRequest(t, http.MethodGet, commentURL, "secret", "")
+	require.Equal(t, http.StatusOK, status, body)
+	var info githubcomment.Info
+	require.NoError(t, json.Unmarshal([]byte(body), &info))
+	require.Equal(t, githubcomment.ID("ci/lint"), info.ID)
+	require.Equal(t, "Hello World", info.Body)
+
+	status, body = doRequest(t, http.MethodGet, s.URL+"/repos/owner/repo/issues/1/comments", "secret", "")
+	require.Equal(t, http.StatusOK, status, body)
+	var infos []githubcomment.Info
+	require.NoError(t, json.Unmarshal([]byte(body), &infos))
+	require.Len(t, infos, 1)
+
+	status, _ = doRequest(t, http.MethodPost, commentURL, "secret", "")
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+	status, _ = doRequest(t, http.MethodGet, s.URL+"/repos/owner/repo1/repo2/issues/1/comments", "secret", "")
+	require.Equal(t, http.StatusNotFound, status)
+	status, _ = doRequest(t, http.MethodGet, s.URL+"/repos/-owner/repo/issues/1/comments", "secret", "")
+	require.Equal(t, http.StatusBadRequest, status)
+}
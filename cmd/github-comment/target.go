@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/Eun/github-comment/detect"
+)
+
+// targetCommentID is the comment that the --target points to directly, 0 if it does not point to one
+var targetCommentID int64
+
+// targetHost is the github enterprise host of the --target, empty for github.com
+var targetHost string
+
+// applyTarget sets the repository and the issue from the --target
+func applyTarget() {
+	if *targetFlag == "" {
+		return
+	}
+	if *repositoryFlag != "" || *issueFlag != 0 || *prFlag != 0 || *branchFlag != "" || *shaFlag != "" {
+		fail(errors.New("--target cannot be combined with --repo, --issue, --pr, --branch or --sha"))
+	}
+	target, err := detect.ParseTarget(*targetFlag)
+	if err != nil {
+		fail(err)
+	}
+	*repositoryFlag = target.Owner + "/" + target.Repository
+	*issueFlag = target.Number
+	targetCommentID = target.CommentID
+	targetHost = target.Host
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Eun/github-comment/detect"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var errNoToken = errors.New("no token found, set GITHUB_TOKEN (or GH_TOKEN), pass --token-env or --token-file, log in with gh or add the api host to ~/.netrc")
+
+// apiHost returns the host of the api url, github.com if the url is empty or points to api.github.com
+func apiHost(apiURL string) string {
+	if apiURL == "" {
+		return "github.com"
+	}
+	host := apiURL
+	if u, err := url.Parse(apiURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return githubHost(host)
+}
+
+// githubHost maps the api host of github.com (and an empty host) to github.com
+func githubHost(host string) string {
+	host = strings.ToLower(host)
+	if host == "" || host == "api.github.com" {
+		return "github.com"
+	}
+	return host
+}
+
+// resolveToken finds the token for the host, the sources are tried in this order:
+// the variable of --token-env, the --token-file, GH_TOKEN and GITHUB_TOKEN (for github.com)
+// or GH_ENTERPRISE_TOKEN, GITHUB_ENTERPRISE_TOKEN and GITHUB_TOKEN (for other hosts),
+// the hosts.yml of the gh cli and the netrc file.
+// The returned source describes where the token was found, it never contains the token itself.
+func resolveToken(env detect.Environment, host, tokenEnv, tokenFile string) (token, source string, err error) {
+	if tokenEnv != "" {
+		if token = strings.TrimSpace(env.Getenv(tokenEnv)); token == "" {
+			return "", "", fmt.Errorf("environment %s is not set", tokenEnv)
+		}
+		return token, "environment " + tokenEnv, nil
+	}
+	if tokenFile != "" {
+		buf, err := env.ReadFile(tokenFile)
+		if err != nil {
+			return "", "", fmt.Errorf("unable to read token file: %v", err)
+		}
+		if token = strings.TrimSpace(string(buf)); token == "" {
+			return "", "", fmt.Errorf("token file `%s' is empty", tokenFile)
+		}
+		return token, "file " + tokenFile, nil
+	}
+
+	host = githubHost(host)
+	names := []string{"GH_TOKEN", "GITHUB_TOKEN"}
+	if host != "github.com" {
+		names = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN", "GITHUB_TOKEN"}
+	}
+	for _, name := range names {
+		if token = strings.TrimSpace(env.Getenv(name)); token != "" {
+			return token, "environment " + name, nil
+		}
+	}
+
+	if path := ghHostsFile(env); path != "" {
+		if token = ghHostsToken(env, path, host); token != "" {
+			return token, fmt.Sprintf("gh config %s (%s)", path, host), nil
+		}
+	}
+
+	if path := netrcFile(env); path != "" {
+		machines := []string{host}
+		if host == "github.com" {
+			machines = []string{"api.github.com", "github.com"}
+		}
+		for _, machine := range machines {
+			if token = netrcToken(env, path, machine); token != "" {
+				return token, fmt.Sprintf("netrc %s (%s)", path, machine), nil
+			}
+		}
+	}
+	return "", "", errNoToken
+}
+
+// homeDir returns the home directory of the user
+func homeDir(env detect.Environment) string {
+	if home := env.Getenv("HOME"); home != "" {
+		return home
+	}
+	return env.Getenv("USERPROFILE")
+}
+
+// ghHostsFile returns the path of the hosts.yml of the gh cli
+func ghHostsFile(env detect.Environment) string {
+	if dir := env.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml")
+	}
+	if dir := env.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml")
+	}
+	if dir := env.Getenv("AppData"); dir != "" {
+		return filepath.Join(dir, "GitHub CLI", "hosts.yml")
+	}
+	if home := homeDir(env); home != "" {
+		return filepath.Join(home, ".config", "gh", "hosts.yml")
+	}
+	return ""
+}
+
+// ghHostsToken returns the oauth_token of the host in the hosts.yml of the gh cli,
+// tokens that gh keeps in the keyring of the system cannot be read
+func ghHostsToken(env detect.Environment, path, host string) string {
+	buf, err := env.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var hosts map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	}
+	if err := yaml.Unmarshal(buf, &hosts); err != nil {
+		return ""
+	}
+	for name, h := range hosts {
+		if strings.EqualFold(name, host) {
+			return strings.TrimSpace(h.OAuthToken)
+		}
+	}
+	return ""
+}
+
+// netrcFile returns the path of the netrc file
+func netrcFile(env detect.Environment) string {
+	if path := env.Getenv("NETRC"); path != "" {
+		return path
+	}
+	if home := homeDir(env); home != "" {
+		return filepath.Join(home, ".netrc")
+	}
+	return ""
+}
+
+// netrcToken returns the password of the machine in the netrc file,
+// the default entry is not used so the token is not sent to hosts it was not meant for
+func netrcToken(env detect.Environment, path, machine string) string {
+	buf, err := env.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(buf)))
+	scanner.Split(bufio.ScanWords)
+	var current, password string
+	inMacro := false
+	for scanner.Scan() {
+		word := scanner.Text()
+		if inMacro {
+			// macro definitions end with an empty line, which the word scanner does not see,
+			// so skip to the next entry
+			if word != "machine" && word != "default" {
+				continue
+			}
+			inMacro = false
+		}
+		switch word {
+		case "machine", "default":
+			if strings.EqualFold(current, machine) && password != "" {
+				return password
+			}
+			current, password = "", ""
+			if word == "machine" && scanner.Scan() {
+				current = scanner.Text()
+			}
+		case "password":
+			if scanner.Scan() {
+				password = scanner.Text()
+			}
+		case "login", "account":
+			scanner.Scan()
+		case "macdef":
+			scanner.Scan()
+			inMacro = true
+		}
+	}
+	if strings.EqualFold(current, machine) {
+		return password
+	}
+	return ""
+}
+
+// clientAPIURL returns the api url of the --target or the config, empty for github.com
+func clientAPIURL() string {
+	if targetHost != "" {
+		return "https://" + targetHost + "/api/v3/"
+	}
+	return cfg.APIURL
+}
+
+// showTokenSource prints where the token is taken from, never the token itself
+func showTokenSource() {
+	applyTarget()
+	host := apiHost(clientAPIURL())
+	_, source, err := resolveToken(detect.OSEnvironment(), host, cfg.TokenEnv, cfg.TokenFile)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stdout, "token for %s from %s\n", host, source)
+	os.Exit(0)
+}
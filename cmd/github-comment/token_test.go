@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEnvironment is an environment with in memory variables and files
+type testEnvironment struct {
+	vars  map[string]string
+	files map[string]string
+}
+
+func (e testEnvironment) Getenv(key string) string {
+	return e.vars[key]
+}
+
+func (e testEnvironment) ReadFile(name string) ([]byte, error) {
+	s, ok := e.files[filepath.ToSlash(name)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(s), nil
+}
+
+func TestResolveToken(t *testing.T) {
+	env := testEnvironment{
+		vars: map[string]string{"HOME": "/home/user"},
+		files: map[string]string{
+			"/home/user/.config/gh/hosts.yml": "github.com:\n    oauth_token: gh-token\n    user: octocat\n",
+			"/home/user/.netrc":               "machine api.github.com login octocat password netrc-token\nmachine ghe.example.com\n  login octocat\n  password ghe-netrc-token\n",
+			"/secrets/token":                  "file-token\n",
+		},
+	}
+
+	tests := []struct {
+		Host      string
+		TokenEnv  string
+		TokenFile string
+		Vars      map[string]string
+		Token     string
+		Source    string
+		Error     string
+	}{
+		{"github.com", "", "", nil, "gh-token", "gh config /home/user/.config/gh/hosts.yml (github.com)", ""},
+		{"ghe.example.com", "", "", nil, "ghe-netrc-token", "netrc /home/user/.netrc (ghe.example.com)", ""},
+		{"github.com", "", "", map[string]string{"GITHUB_TOKEN": "github-token"}, "github-token", "environment GITHUB_TOKEN", ""},
+		{"github.com", "", "", map[string]string{"GITHUB_TOKEN": "github-token", "GH_TOKEN": "gh-env-token"}, "gh-env-token", "environment GH_TOKEN", ""},
+		{"ghe.example.com", "", "", map[string]string{"GH_TOKEN": "gh-env-token", "GH_ENTERPRISE_TOKEN": "ghe-token"}, "ghe-token", "environment GH_ENTERPRISE_TOKEN", ""},
+		{"github.com", "", "/secrets/token", map[string]string{"GH_TOKEN": "gh-env-token"}, "file-token", "file /secrets/token", ""},
+		{"github.com", "MY_TOKEN", "/secrets/token", map[string]string{"MY_TOKEN": "my-token"}, "my-token", "environment MY_TOKEN", ""},
+		{"github.com", "MY_TOKEN", "", nil, "", "", "environment MY_TOKEN is not set"},
+		{"other.example.com", "", "", nil, "", "", errNoToken.Error()},
+	}
+
+	for _, test := range tests {
+		vars := map[string]string{"HOME": "/home/user"}
+		for key, value := range test.Vars {
+			vars[key] = value
+		}
+		env.vars = vars
+		token, source, err := resolveToken(env, test.Host, test.TokenEnv, test.TokenFile)
+		if test.Error != "" {
+			require.EqualError(t, err, test.Error)
+		} else {
+			require.NoError(t, err)
+		}
+		require.Equal(t, test.Token, token)
+		require.Equal(t, test.Source, source)
+	}
+}
+
+func TestNetrcToken(t *testing.T) {
+	env := testEnvironment{files: map[string]string{
+		"netrc": "macdef init\ncd /\n\nmachine github.com login octocat password a\ndefault login anonymous password b\n",
+	}}
+	require.Equal(t, "a", netrcToken(env, "netrc", "github.com"))
+	require.Equal(t, "", netrcToken(env, "netrc", "example.com"))
+}
+
+func TestAPIHost(t *testing.T) {
+	require.Equal(t, "github.com", apiHost(""))
+	require.Equal(t, "ghe.example.com", apiHost("https://ghe.example.com/api/v3/"))
+	require.Equal(t, "github.com", apiHost("https://api.github.com/"))
+	require.Equal(t, "github.com", apiHost("https://API.github.com"))
+
+	// the token of github.com is used for api.github.com
+	env := testEnvironment{vars: map[string]string{"GH_TOKEN": "a", "GH_ENTERPRISE_TOKEN": "b"}}
+	token, source, err := resolveToken(env, apiHost("https://api.github.com/"), "", "")
+	require.NoError(t, err)
+	require.Equal(t, "a", token)
+	require.Equal(t, "environment GH_TOKEN", source)
+	token, _, err = resolveToken(env, "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "a", token)
+}
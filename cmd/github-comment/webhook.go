@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/google/go-github/github"
+)
+
+// defaultAssociations are the author associations that may use a slash command if it does not specify them
+var defaultAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// slashCommand changes a managed comment when someone comments `/name args...`,
+// strings in Meta, Append and Template are templates with .ID, .User, .Args, .Arg (the joined args), .Text and .Meta
+type slashCommand struct {
+	// ID is the id of the managed comment
+	ID string `yaml:"id"`
+	// Meta is a json merge patch that is applied to the meta
+	Meta map[string]interface{} `yaml:"meta,omitempty"`
+	// Append adds the values to the lists in the meta
+	Append map[string]interface{} `yaml:"append,omitempty"`
+	// Template renders the body again (with the patched meta), the body is kept if it is empty
+	Template string `yaml:"template,omitempty"`
+	// Associations are the author associations (OWNER, MEMBER, COLLABORATOR, CONTRIBUTOR, ...) that may use the command
+	Associations []string `yaml:"associations,omitempty"`
+}
+
+// commandResult is reported for every slash command of a comment
+type commandResult struct {
+	Command string                `json:"command"`
+	Args    []string              `json:"args"`
+	Result  *githubcomment.Result `json:"result,omitempty"`
+	Error   string                `json:"error,omitempty"`
+}
+
+// webhook receives the issue_comment events of github and applies the slash commands
+type webhook struct {
+	comment  githubcomment.GithubComment
+	secret   []byte
+	commands map[string]*slashCommand
+	// mu serializes the commands, so concurrent deliveries do not overwrite each others meta
+	mu sync.Mutex
+}
+
+func (h *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, 25*1024*1024))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !validSignature(h.secret, payload, r.Header.Get("X-Hub-Signature-256")) {
+		writeError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	switch r.Header.Get("X-GitHub-Event") {
+	case "ping":
+		writeResponse(w, http.StatusOK, map[string]string{"status": "pong"}, nil)
+		return
+	case "issue_comment":
+	default:
+		writeResponse(w, http.StatusOK, map[string]string{"status": "ignored"}, nil)
+		return
+	}
+
+	var event github.IssueCommentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid payload: %v", err))
+		return
+	}
+	results := h.handleIssueComment(&event)
+	if results == nil {
+		results = []*commandResult{}
+	}
+	writeResponse(w, http.StatusOK, results, nil)
+}
+
+// handleIssueComment applies the slash commands of a new comment
+func (h *webhook) handleIssueComment(event *github.IssueCommentEvent) []*commandResult {
+	if event.GetAction() != "created" || event.GetComment().GetUser().GetType() == "Bot" {
+		return nil
+	}
+	gc := h.comment
+	gc.Owner = event.GetRepo().GetOwner().GetLogin()
+	gc.Repository = event.GetRepo().GetName()
+	user := event.GetComment().GetUser().GetLogin()
+	association := event.GetComment().GetAuthorAssociation()
+
+	var results []*commandResult
+	for _, args := range parseSlashCommands(event.GetComment().GetBody()) {
+		cmd := h.commands[args[0]]
+		if cmd == nil {
+			continue
+		}
+		result := &commandResult{Command: args[0], Args: args[1:]}
+		results = append(results, result)
+		if !cmd.allows(association) {
+			result.Error = fmt.Sprintf("`%s' (%s) is not allowed to use /%s", user, association, args[0])
+			continue
+		}
+		h.mu.Lock()
+		res, err := cmd.apply(&gc, event.GetIssue().GetNumber(), user, args[1:])
+		h.mu.Unlock()
+		result.Result = res
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return results
+}
+
+// allows reports whether authors with the association may use the command
+func (c *slashCommand) allows(association string) bool {
+	associations := c.Associations
+	if len(associations) == 0 {
+		associations = defaultAssociations
+	}
+	for _, a := range associations {
+		if strings.EqualFold(a, association) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply patches the meta (and renders the body) of the managed comment
+func (c *slashCommand) apply(gc *githubcomment.GithubComment, issueID int, user string, args []string) (*githubcomment.Result, error) {
+	id := githubcomment.ID(c.ID)
+	info, err := gc.GetIssueComment(issueID, id)
+	if errors.As(err, &githubcomment.IssueCommentNotFoundError{}) {
+		info, err = &githubcomment.Info{ID: id}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the meta is patched, so it has to be readable
+	if err := info.MetaErr(); err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"ID":   c.ID,
+		"User": user,
+		"Args": args,
+		"Arg":  strings.Join(args, " "),
+		"Text": info.Body,
+		"Meta": info.Meta,
+	}
+	meta := info.Meta
+	if c.Meta != nil {
+		patch, err := renderValue(c.ID, c.Meta, data)
+		if err != nil {
+			return nil, err
+		}
+		meta = mergePatch(meta, patch)
+	}
+	for key, value := range c.Append {
+		v, err := renderValue(c.ID, value, data)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := meta.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+		}
+		list, _ := m[key].([]interface{})
+		m[key] = append(list, v)
+		meta = m
+	}
+
+	body := info.Body
+	if c.Template != "" {
+		data["Meta"] = meta
+		if body, err = renderTemplateData(c.ID, c.Template, data); err != nil {
+			return nil, err
+		}
+	}
+	return gc.UpdateIssueComment(issueID, id, body, meta)
+}
+
+// parseSlashCommands returns the commands (with their args) of the lines that start with a slash,
+// lines in code blocks are ignored
+func parseSlashCommands(body string) [][]string {
+	var commands [][]string
+	inCode := false
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode || !strings.HasPrefix(line, "/") {
+			continue
+		}
+		if fields := strings.Fields(line[1:]); len(fields) > 0 {
+			commands = append(commands, fields)
+		}
+	}
+	return commands
+}
+
+// mergePatch applies a json merge patch (rfc 7386) to the target
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergePatch(t[key], value)
+	}
+	return t
+}
+
+// renderValue returns a copy of v with all strings rendered as templates,
+// the maps of yaml.v2 are converted to map[string]interface{} on the way
+func renderValue(name string, v interface{}, data map[string]interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return renderTemplateData(name, v, data)
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			rendered, err := renderValue(name, value, data)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key)] = rendered
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			rendered, err := renderValue(name, value, data)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = rendered
+		}
+		return m, nil
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, value := range v {
+			rendered, err := renderValue(name, value, data)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = rendered
+		}
+		return l, nil
+	}
+	return v, nil
+}
+
+// validSignature verifies the X-Hub-Signature-256 header of a payload
+func validSignature(secret, payload []byte, header string) bool {
+	const prefix = "sha256="
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, prefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// readWebhookSecret reads the secret from the --secret-file or the environment GITHUB_COMMENT_WEBHOOK_SECRET
+func readWebhookSecret() ([]byte, error) {
+	secret := []byte(os.Getenv("GITHUB_COMMENT_WEBHOOK_SECRET"))
+	if *webhookSecretFile != "" {
+		buf, err := ioutil.ReadFile(*webhookSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook secret file: %v", err)
+		}
+		secret = []byte(strings.TrimSpace(string(buf)))
+	}
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("webhook needs the secret of the webhook, set GITHUB_COMMENT_WEBHOOK_SECRET or pass --secret-file")
+	}
+	return secret, nil
+}
+
+func receiveWebhooks() {
+	secret, err := readWebhookSecret()
+	if err != nil {
+		fail(err)
+	}
+	if len(cfg.Commands) == 0 {
+		fail(errors.New("no slash commands configured, add them to the commands of the config file"))
+	}
+	initClient(false)
+	listenAndServe(*webhookListen, &webhook{
+		comment:  comment,
+		secret:   secret,
+		commands: cfg.Commands,
+	})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	githubcomment "github.com/Eun/github-comment"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const testCommands = `
+retry:
+  id: ci
+  meta:
+    retry: "{{.Arg}}"
+    retried_by: "{{.User}}"
+  template: "retry of {{.Meta.retry}} requested"
+ack:
+  id: ci
+  append:
+    acked: "{{.Arg}}"
+`
+
+func signPayload(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookRequest(t *testing.T, event string, payload []byte, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", signature)
+	return req
+}
+
+func TestWebhook(t *testing.T) {
+	var commands map[string]*slashCommand
+	require.NoError(t, yaml.Unmarshal([]byte(testCommands), &commands))
+	gc := githubcomment.GithubComment{Client: newGithubStandIn(t), Context: context.Background(), Owner: "owner", Repository: "repo"}
+	h := &webhook{comment: gc, secret: []byte("secret"), commands: commands}
+
+	payload, err := ioutil.ReadFile("testdata/issue_comment.json")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newWebhookRequest(t, "issue_comment", payload, signPayload([]byte("wrong"), payload)))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newWebhookRequest(t, "issue_comment", payload, signPayload([]byte("secret"), payload)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var results []*commandResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 2)
+	require.Equal(t, "retry", results[0].Command)
+	require.Empty(t, results[0].Error)
+	require.Equal(t, "ack", results[1].Command)
+	require.Empty(t, results[1].Error)
+
+	info, err := gc.GetIssueComment(1, "ci")
+	require.NoError(t, err)
+	require.Equal(t, "retry of lint requested", info.Body)
+	require.Equal(t, map[string]interface{}{
+		"retry":      "lint",
+		"retried_by": "octocat",
+		"acked":      []interface{}{"flaky-test"},
+	}, info.Meta)
+
+	// contributors are not allowed to use the commands by default
+	payload = []byte(strings.Replace(string(payload), `"MEMBER"`, `"CONTRIBUTOR"`, 1))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newWebhookRequest(t, "issue_comment", payload, signPayload([]byte("secret"), payload)))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Equal(t, "`octocat' (CONTRIBUTOR) is not allowed to use /retry", results[0].Error)
+}
+
+func TestParseSlashCommands(t *testing.T) {
+	require.Equal(t, [][]string{{"retry", "lint"}, {"ack"}}, parseSlashCommands("text\n  /retry lint\n```\n/skip\n```\n/ack\n/"))
+}
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{"a": "b", "c": map[string]interface{}{"d": "e", "f": "g"}}
+	patch := map[string]interface{}{"a": "z", "c": map[string]interface{}{"f": nil}}
+	require.Equal(t, map[string]interface{}{"a": "z", "c": map[string]interface{}{"d": "e"}}, mergePatch(target, patch))
+	require.Equal(t, map[string]interface{}{"a": "b"}, mergePatch([]interface{}{1}, map[string]interface{}{"a": "b"}))
+}
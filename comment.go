@@ -0,0 +1,171 @@
+package githubcomment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+type UntrustedAuthorError struct {
+	CommentID int64
+	Author    string
+}
+
+func (e UntrustedAuthorError) Error() string {
+	return fmt.Sprintf("comment %d was written by `%s' who is not a trusted author", e.CommentID, e.Author)
+}
+
+type UnverifiedCommentError struct {
+	CommentID int64
+}
+
+func (e UnverifiedCommentError) Error() string {
+	return fmt.Sprintf("the signature of comment %d could not be verified", e.CommentID)
+}
+
+type IDMismatchError struct {
+	CommentID int64
+	ID        ID
+	Found     ID
+}
+
+func (e IDMismatchError) Error() string {
+	if e.Found == "" {
+		return fmt.Sprintf("comment %d has no id, it is not overwritten with the id `%s'", e.CommentID, string(e.ID))
+	}
+	return fmt.Sprintf("comment %d has the id `%s', not `%s'", e.CommentID, string(e.Found), string(e.ID))
+}
+
+// issueCommentByID fetches the comment with the comment id and returns it with the number of its issue,
+// if TrustedAuthors is set, comments of other authors result in an UntrustedAuthorError,
+// if SkipUnverified is set, comments without a valid signature result in an UnverifiedCommentError
+func (gc *GithubComment) issueCommentByID(commentID int64) (*github.IssueComment, int, error) {
+	authors, err := gc.trustedAuthors()
+	if err != nil {
+		return nil, 0, err
+	}
+	comment, _, err := gc.Client.Issues.GetComment(gc.Context, gc.Owner, gc.Repository, commentID)
+	if err != nil {
+		return nil, 0, apiError(err)
+	}
+	if !isTrustedAuthor(comment.GetUser(), authors) {
+		return nil, 0, UntrustedAuthorError{CommentID: commentID, Author: comment.GetUser().GetLogin()}
+	}
+	issueID := issueNumber(comment)
+	if !gc.isVerified(issueID, comment.GetBody()) {
+		return nil, 0, UnverifiedCommentError{CommentID: commentID}
+	}
+	return comment, issueID, nil
+}
+
+// issueNumber returns the number of the issue the comment belongs to, 0 if it is unknown
+func issueNumber(comment *github.IssueComment) int {
+	u := comment.GetIssueURL()
+	n, err := strconv.Atoi(u[strings.LastIndexByte(u, '/')+1:])
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// GetIssueCommentByID returns the info for the comment with the comment id,
+// the comment is addressed directly so there is no search for the marker
+func (gc *GithubComment) GetIssueCommentByID(commentID int64) (*Info, error) {
+	comment, issueID, err := gc.issueCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := gc.parseInfo(issueID, comment.GetBody())
+	if err != nil {
+		return nil, err
+	}
+	info.setComment(comment)
+	return info, nil
+}
+
+// UpdateIssueCommentByID updates the comment with the comment id,
+// if the id is empty the id of the existing marker is kept (or a new one is generated if the comment has none),
+// otherwise it must match the id of the marker
+func (gc *GithubComment) UpdateIssueCommentByID(commentID int64, id ID, text string, meta interface{}) (*Result, error) {
+	comment, issueID, err := gc.issueCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if id, err = gc.commentID(comment, id); err != nil {
+		return nil, err
+	}
+	info := Info{
+		ID:   id,
+		Body: text,
+		Meta: meta,
+	}
+	gc.archive(comment.GetBody(), comment.UpdatedAt, &info)
+	return gc.editIssueComment(issueID, nil, comment, &info)
+}
+
+// SetIssueCommentMetaByID replaces the meta of the comment with the comment id and keeps its body
+func (gc *GithubComment) SetIssueCommentMetaByID(commentID int64, id ID, meta interface{}) (*Result, error) {
+	comment, issueID, err := gc.issueCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if id, err = gc.commentID(comment, id); err != nil {
+		return nil, err
+	}
+	// the current meta is not validated, so invalid meta can be replaced
+	info, err := ParseInfo(comment.GetBody())
+	if err != nil {
+		return nil, err
+	}
+	if err := gc.checkDecryptable(info); err != nil {
+		return nil, err
+	}
+	return gc.editIssueComment(issueID, nil, comment, &Info{
+		ID:      id,
+		Body:    info.Body,
+		Meta:    meta,
+		History: info.History,
+	})
+}
+
+// commentID returns the id of the comments marker (or a new one if it has none) if id is empty,
+// otherwise the id if it matches the marker, so a comment of another id is not taken over
+func (gc *GithubComment) commentID(comment *github.IssueComment, id ID) (ID, error) {
+	found := ID("")
+	if info, err := ParseInfo(comment.GetBody()); err == nil {
+		found = info.ID
+	}
+	switch {
+	case id == "" && found == "":
+		return ID(id.GetID()), nil
+	case id == "":
+		return found, nil
+	case found.Canonical() != id.Canonical():
+		return "", IDMismatchError{CommentID: comment.GetID(), ID: id, Found: found}
+	}
+	return id, nil
+}
+
+// HideIssueCommentByID minimizes the comment with the comment id as outdated
+func (gc *GithubComment) HideIssueCommentByID(commentID int64) error {
+	comment, _, err := gc.issueCommentByID(commentID)
+	if err != nil {
+		return err
+	}
+	return gc.MinimizeIssueComment(comment, MinimizeOutdated)
+}
+
+// DeleteIssueCommentByID deletes the comment with the comment id
+func (gc *GithubComment) DeleteIssueCommentByID(commentID int64) (*Result, error) {
+	comment, issueID, err := gc.issueCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := gc.commentID(comment, "")
+	if err != nil {
+		return nil, err
+	}
+	return gc.deleteIssueComment(issueID, id, comment)
+}
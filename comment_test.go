@@ -0,0 +1,81 @@
+package githubcomment
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateIssueCommentByID(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	// two comments with the same id, the second one is addressed directly
+	f.addComment("bot", makeMagicMarker(ID("build"))+"\nfirst")
+	second := f.addComment("bot", makeMagicMarker(ID("build"))+"\nsecond")
+
+	result, err := gc.UpdateIssueCommentByID(second.GetID(), "", "Hello World", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+	require.Equal(t, ID("build"), result.ID)
+	require.Equal(t, second.GetID(), result.CommentID)
+
+	info, err := gc.GetIssueCommentByID(second.GetID())
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", info.Body)
+
+	first, err := gc.GetIssueCommentByID(f.comments[0].GetID())
+	require.NoError(t, err)
+	require.Equal(t, "first", first.Body)
+
+	result, err = gc.SetIssueCommentMetaByID(second.GetID(), "", map[string]interface{}{"ok": true})
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+	require.Equal(t, "Hello World", result.Info.Body)
+}
+
+func TestUpdateIssueCommentByIDWithoutMarker(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	plain := f.addComment("bot", "plain text")
+
+	result, err := gc.UpdateIssueCommentByID(plain.GetID(), "", "Hello World", nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.ID)
+	require.Contains(t, plain.GetBody(), makeMagicMarker(result.ID))
+}
+
+func TestUpdateIssueCommentByIDMismatch(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	other := f.addComment("bot", makeMagicMarker(ID("deploy"))+"\nHello")
+	plain := f.addComment("bot", "plain text")
+
+	_, err := gc.UpdateIssueCommentByID(other.GetID(), ID("build"), "Hello World", nil)
+	require.Equal(t, IDMismatchError{CommentID: other.GetID(), ID: ID("build"), Found: ID("deploy")}, err)
+	require.True(t, errors.Is(err, ConflictError{}))
+	require.Contains(t, other.GetBody(), "Hello")
+
+	_, err = gc.SetIssueCommentMetaByID(plain.GetID(), ID("build"), nil)
+	require.Equal(t, IDMismatchError{CommentID: plain.GetID(), ID: ID("build")}, err)
+	require.Equal(t, "plain text", plain.GetBody())
+
+	result, err := gc.UpdateIssueCommentByID(other.GetID(), ID("deploy"), "Hello World", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+}
+
+func TestUpdateIssueCommentByIDUntrustedAuthor(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.TrustedAuthors = []string{Me}
+	other := f.addComment("someone", makeMagicMarker(ID("build"))+"\nHello")
+
+	_, err := gc.UpdateIssueCommentByID(other.GetID(), "", "Hello World", nil)
+	require.Equal(t, UntrustedAuthorError{CommentID: other.GetID(), Author: "someone"}, err)
+}
+
+func TestHideIssueCommentByID(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	first := f.addComment("bot", makeMagicMarker(ID("build"))+"\nfirst")
+	f.addComment("bot", makeMagicMarker(ID("build"))+"\nsecond")
+
+	require.NoError(t, gc.HideIssueCommentByID(first.GetID()))
+	require.Equal(t, map[string]string{first.GetNodeID(): string(MinimizeOutdated)}, f.minimized)
+}
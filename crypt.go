@@ -0,0 +1,173 @@
+package githubcomment
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// encryptedMetaMarker is the field that identifies encrypted meta,
+// it is specific enough to not collide with the fields of plain meta
+const encryptedMetaMarker = "$github-comment-encrypted"
+
+// MetaKey is a key that is used to encrypt and decrypt the meta with AES-GCM
+type MetaKey struct {
+	// ID identifies the key, so keys can be rotated
+	ID string
+	// Key must be 16, 24 or 32 bytes long
+	Key []byte
+}
+
+type MetaKeyNotFoundError struct {
+	KeyID string
+}
+
+func (e MetaKeyNotFoundError) Error() string {
+	if e.KeyID == "" {
+		return "meta is encrypted but no key is available"
+	}
+	return fmt.Sprintf("meta is encrypted with the key `%s' which is not available", e.KeyID)
+}
+
+// ParseMetaKeys parses a list of keys separated by commas or new lines,
+// each key has the form id:base64key or just base64key
+func ParseMetaKeys(s string) ([]MetaKey, error) {
+	var keys []MetaKey
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == '\r' }) {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, "#") {
+			continue
+		}
+		var key MetaKey
+		encoded := field
+		if i := strings.IndexByte(field, ':'); i != -1 {
+			key.ID = field[:i]
+			encoded = field[i+1:]
+		}
+		var err error
+		key.Key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			key.Key, err = base64.RawStdEncoding.DecodeString(encoded)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode key `%s': %v", key.ID, err)
+		}
+		switch len(key.Key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("key `%s' must be 16, 24 or 32 bytes long", key.ID)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// encryptMeta encrypts the meta with the key, the id is used as additional data so the
+// encrypted meta cannot be moved to a comment with another id
+func encryptMeta(key MetaKey, id ID, meta interface{}) (interface{}, error) {
+	plain, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nonce, nonce, plain, []byte(id.Canonical()))
+	envelope := map[string]interface{}{
+		encryptedMetaMarker: "aes-gcm",
+		"data":              base64.StdEncoding.EncodeToString(sealed),
+	}
+	if key.ID != "" {
+		envelope["kid"] = key.ID
+	}
+	return envelope, nil
+}
+
+// isEncryptedMeta reports whether the meta is an envelope of encrypted meta,
+// plain meta that happens to contain the marker is not mistaken for one
+func isEncryptedMeta(meta interface{}) bool {
+	envelope, ok := meta.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for field, value := range envelope {
+		switch field {
+		case encryptedMetaMarker, "data", "kid":
+			if _, ok := value.(string); !ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	_, hasMarker := envelope[encryptedMetaMarker]
+	_, hasData := envelope["data"]
+	return hasMarker && hasData
+}
+
+// encryptedMetaKeyID returns the id of the key the meta was encrypted with
+func encryptedMetaKeyID(meta interface{}) (string, bool) {
+	if !isEncryptedMeta(meta) {
+		return "", false
+	}
+	kid, _ := meta.(map[string]interface{})["kid"].(string)
+	return kid, true
+}
+
+// decryptMeta decrypts the meta with the matching keys, all keys with the id of the envelope are tried
+func decryptMeta(keys []MetaKey, id ID, meta interface{}) (interface{}, error) {
+	envelope := meta.(map[string]interface{})
+	if algorithm, _ := envelope[encryptedMetaMarker].(string); algorithm != "aes-gcm" {
+		return nil, fmt.Errorf("unsupported meta encryption `%v'", envelope[encryptedMetaMarker])
+	}
+	kid, _ := envelope["kid"].(string)
+	data, _ := envelope["data"].(string)
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode encrypted meta: %v", err)
+	}
+
+	var lastErr error = MetaKeyNotFoundError{KeyID: kid}
+	for _, key := range keys {
+		if key.ID != kid {
+			continue
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return nil, errors.New("unable to decrypt meta: data is too short")
+		}
+		plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id.Canonical()))
+		if err != nil {
+			lastErr = fmt.Errorf("unable to decrypt meta: %v", err)
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(plain, &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, lastErr
+}
+
+func newAEAD(key MetaKey) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,112 @@
+package githubcomment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetaKeys(t *testing.T) {
+	keys, err := ParseMetaKeys("k2:AAAAAAAAAAAAAAAAAAAAAA==,\n# old key\nk1:AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.Equal(t, "k2", keys[0].ID)
+	require.Len(t, keys[0].Key, 16)
+	require.Equal(t, "k1", keys[1].ID)
+	require.Len(t, keys[1].Key, 32)
+
+	keys, err = ParseMetaKeys("AAAAAAAAAAAAAAAAAAAAAA")
+	require.NoError(t, err)
+	require.Equal(t, "", keys[0].ID)
+
+	_, err = ParseMetaKeys("k1:AAAA")
+	require.EqualError(t, err, "key `k1' must be 16, 24 or 32 bytes long")
+}
+
+func TestEncryptedMeta(t *testing.T) {
+	k1 := MetaKey{ID: "k1", Key: []byte(strings.Repeat("1", 32))}
+	k2 := MetaKey{ID: "k2", Key: []byte(strings.Repeat("2", 32))}
+
+	f, gc := newFakeGithub(t, "")
+	gc.MetaKeys = []MetaKey{k1}
+
+	result, err := gc.PostIssueComment(1, ID("deploy"), "Hello World", map[string]interface{}{"secret": "value"})
+	require.NoError(t, err)
+	require.NotContains(t, f.comments[0].GetBody(), "value")
+
+	// unchanged content is detected even though the ciphertext differs
+	result, err = gc.UpdateIssueComment(1, ID("deploy"), "Hello World", map[string]interface{}{"secret": "value"})
+	require.NoError(t, err)
+	require.Equal(t, ActionUnchanged, result.Action)
+
+	// rotate the key, the old one is still used for decryption
+	gc.MetaKeys = []MetaKey{k2, k1}
+	info, err := gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"secret": "value"}, info.Meta)
+
+	// without the key the body is still readable, only the meta is missing
+	gc.MetaKeys = []MetaKey{k2}
+	info, err = gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.Equal(t, "Hello World", info.Body)
+	require.Nil(t, info.Meta)
+	require.Equal(t, MetaKeyNotFoundError{KeyID: "k1"}, info.MetaErr())
+	require.Equal(t, MetaKeyNotFoundError{KeyID: "k1"}.Error(), info.MetaError)
+	infos, err := gc.ListIssueComments(1)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	require.Equal(t, "Hello World", infos[0].Body)
+
+	// meta that cannot be decrypted is not replaced
+	_, err = gc.SetIssueCommentMeta(1, ID("deploy"), map[string]interface{}{"secret": "other"})
+	require.Equal(t, MetaKeyNotFoundError{KeyID: "k1"}, err)
+	_, err = gc.SetIssueCommentMetaByID(f.comments[0].GetID(), "", map[string]interface{}{"secret": "other"})
+	require.Equal(t, MetaKeyNotFoundError{KeyID: "k1"}, err)
+
+	// meta encrypted with a retired key is encrypted again with the current key
+	gc.MetaKeys = []MetaKey{k2, k1}
+	result, err = gc.UpdateIssueComment(1, ID("deploy"), "Hello World", map[string]interface{}{"secret": "value"})
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+	written, err := ParseInfo(f.comments[0].GetBody())
+	require.NoError(t, err)
+	kid, ok := encryptedMetaKeyID(written.Meta)
+	require.True(t, ok)
+	require.Equal(t, "k2", kid)
+	result, err = gc.UpdateIssueComment(1, ID("deploy"), "Hello World", map[string]interface{}{"secret": "value"})
+	require.NoError(t, err)
+	require.Equal(t, ActionUnchanged, result.Action)
+
+	// keys that share an id are all tried
+	gc.MetaKeys = []MetaKey{{ID: "k2", Key: k1.Key}, k2}
+	info, err = gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"secret": "value"}, info.Meta)
+
+	// the encrypted meta is bound to the id
+	f.addComment("bot", strings.Replace(f.comments[0].GetBody(), makeMagicMarker(ID("deploy")), makeMagicMarker(ID("other")), 1))
+	gc.MetaKeys = []MetaKey{k2}
+	info, err = gc.GetIssueComment(1, ID("other"))
+	require.NoError(t, err)
+	require.Nil(t, info.Meta)
+	require.EqualError(t, info.MetaErr(), "unable to decrypt meta: cipher: message authentication failed")
+
+	// unencrypted meta is still readable
+	_, err = gc.UpdateIssueComment(1, ID("plain"), "Hello World", nil)
+	require.NoError(t, err)
+	f.comments[len(f.comments)-1].Body = github.String(makeMagicMarker(ID("plain")) + "<!---{\"a\":1}--->\nHello World")
+	info, err = gc.GetIssueComment(1, ID("plain"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"a": float64(1)}, info.Meta)
+
+	// plain meta is not mistaken for encrypted meta
+	f.comments[len(f.comments)-1].Body = github.String(makeMagicMarker(ID("plain")) + "<!---{\"$encrypted\":true,\"data\":\"x\"}--->\nHello World")
+	info, err = gc.GetIssueComment(1, ID("plain"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"$encrypted": true, "data": "x"}, info.Meta)
+	require.False(t, isEncryptedMeta(map[string]interface{}{encryptedMetaMarker: "aes-gcm", "data": "x", "other": "y"}))
+	require.True(t, isEncryptedMeta(map[string]interface{}{encryptedMetaMarker: "aes-gcm", "data": "x", "kid": "k1"}))
+}
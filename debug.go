@@ -0,0 +1,131 @@
+package githubcomment
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// redacted replaces secrets in the debug log
+const redacted = "[redacted]"
+
+var (
+	// tokenPatterns match the token formats of github
+	tokenPatterns = regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{20,})\b`)
+	// tokenValues match values of fields, parameters and authorization schemes that hold secrets
+	tokenValues = regexp.MustCompile(`(?i)((?:access_token|token|secret|password|client_secret)"?\s*[:=]\s*"?|\b(?:bearer|token|basic)\s+)[^\s"&,]+`)
+)
+
+// redact removes tokens from s
+func redact(s string) string {
+	s = tokenPatterns.ReplaceAllString(s, redacted)
+	return tokenValues.ReplaceAllString(s, "${1}"+redacted)
+}
+
+// isSecretHeader reports whether the header carries credentials
+func isSecretHeader(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "authorization", "proxy-authorization", "cookie", "set-cookie":
+		return true
+	}
+	return strings.Contains(name, "token") || strings.Contains(name, "signature") || strings.Contains(name, "secret")
+}
+
+// DebugTransport logs every request and response (method, url, status, rate limit, timing and bodies),
+// credentials in headers and token like strings are redacted.
+// Use it as the transport (or the base of the transport) of the http client of the github client.
+type DebugTransport struct {
+	// Base is the transport that is wrapped, http.DefaultTransport if nil
+	Base http.RoundTripper
+	// Logger receives the log, the standard logger if nil
+	Logger *log.Logger
+	// MaxBody truncates logged bodies to MaxBody bytes, 0 logs complete bodies
+	MaxBody int
+	// NoBody disables logging of the bodies
+	NoBody bool
+}
+
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	url := redact(req.URL.String())
+	t.printf("--> %s %s", req.Method, url)
+	t.printHeaders(req.Header)
+	if req.Body != nil && !t.NoBody {
+		buf, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(buf))
+		t.printBody(buf)
+	}
+
+	start := time.Now()
+	res, err := base.RoundTrip(req)
+	duration := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		t.printf("<-- %s %s failed after %s: %s", req.Method, url, duration, redact(err.Error()))
+		return nil, err
+	}
+
+	rateLimit := ""
+	if remaining := res.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+		rateLimit = " rate limit " + remaining + "/" + res.Header.Get("X-RateLimit-Limit")
+		if reset := res.Header.Get("X-RateLimit-Reset"); reset != "" {
+			rateLimit += " reset " + reset
+		}
+	}
+	t.printf("<-- %s %s %s (%s)%s", res.Status, req.Method, url, duration, rateLimit)
+	t.printHeaders(res.Header)
+	if res.Body != nil && !t.NoBody {
+		buf, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+		t.printBody(buf)
+	}
+	return res, nil
+}
+
+func (t *DebugTransport) printf(format string, v ...interface{}) {
+	if t.Logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	t.Logger.Printf(format, v...)
+}
+
+func (t *DebugTransport) printHeaders(header http.Header) {
+	for name, values := range header {
+		for _, value := range values {
+			if isSecretHeader(name) {
+				value = redacted
+			}
+			t.printf("    %s: %s", name, redact(value))
+		}
+	}
+}
+
+func (t *DebugTransport) printBody(buf []byte) {
+	if len(buf) == 0 {
+		return
+	}
+	// redact before truncating, a cut off token would not be recognized anymore
+	body := redact(strings.TrimSpace(string(buf)))
+	suffix := ""
+	if t.MaxBody > 0 && len(body) > t.MaxBody {
+		suffix = "... (truncated)"
+		body = body[:t.MaxBody]
+	}
+	t.printf("    %s%s", body, suffix)
+}
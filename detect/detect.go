@@ -0,0 +1,171 @@
+// Package detect detects the repository and the pull request of a build from its environment
+package detect
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Target is the detected repository and pull request
+type Target struct {
+	// Source is the name of the detector that found the target
+	Source     string
+	Owner      string
+	Repository string
+	// Number is the number of the pull request (or issue), 0 if the build does not belong to one
+	Number int
+	// CommentID is the id of a comment the target points to directly, 0 if it does not point to one
+	CommentID int64
+	// Host is the host of a github enterprise server, empty for github.com
+	Host string
+}
+
+// Environment gives detectors access to the environment, so they can be tested with fixtures
+type Environment interface {
+	Getenv(key string) string
+	ReadFile(name string) ([]byte, error)
+}
+
+// Detector detects a target from the environment
+type Detector interface {
+	Name() string
+	// Detect returns nil if the detector does not apply to the environment
+	Detect(env Environment) (*Target, error)
+}
+
+var ErrNotDetected = errors.New("unable to detect the repository from the environment")
+
+var mu sync.Mutex
+var detectors []Detector
+
+// Register adds a detector, detectors are tried in the order they were registered
+func Register(d Detector) {
+	mu.Lock()
+	defer mu.Unlock()
+	detectors = append(detectors, d)
+}
+
+// Detectors returns the registered detectors
+func Detectors() []Detector {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Detector(nil), detectors...)
+}
+
+// Detect returns the target of the first detector that applies to the environment
+func Detect(env Environment) (*Target, error) {
+	for _, d := range Detectors() {
+		target, err := d.Detect(env)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", d.Name(), err)
+		}
+		if target != nil {
+			target.Source = d.Name()
+			return target, nil
+		}
+	}
+	return nil, ErrNotDetected
+}
+
+type osEnvironment struct{}
+
+func (osEnvironment) Getenv(key string) string {
+	return os.Getenv(key)
+}
+
+func (osEnvironment) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+// OSEnvironment returns the environment of the current process
+func OSEnvironment() Environment {
+	return osEnvironment{}
+}
+
+// splitSlug splits owner/repo
+func splitSlug(s string) (owner, repo string, err error) {
+	p := strings.Split(strings.TrimSpace(s), "/")
+	if len(p) != 2 || !ValidOwner(p[0]) || !ValidRepository(p[1]) {
+		return "", "", fmt.Errorf("invalid repository `%s'", s)
+	}
+	return p[0], p[1], nil
+}
+
+// parseNumber parses a pull request number, empty values and "false" result in 0
+func parseNumber(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "false" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid pull request number `%s'", s)
+	}
+	return n, nil
+}
+
+// ParseRepositoryURL parses the owner and repository of git urls like
+// https://github.com/owner/repo.git, ssh://git@github.com/owner/repo.git or git@github.com:owner/repo.git
+func ParseRepositoryURL(s string) (owner, repo string, err error) {
+	p, err := repositoryURLPath(s)
+	if err != nil {
+		return "", "", err
+	}
+	if len(p) < 2 || !ValidOwner(p[len(p)-2]) || !ValidRepository(p[len(p)-1]) {
+		return "", "", fmt.Errorf("invalid repository url `%s'", strings.TrimSpace(s))
+	}
+	return p[len(p)-2], p[len(p)-1], nil
+}
+
+// parseGithubRepositoryURL parses the owner and repository of a git url like ParseRepositoryURL,
+// ok is false for urls that cannot belong to a github repository (local paths or nested paths like group/subgroup/repo)
+func parseGithubRepositoryURL(s string) (owner, repo string, ok bool) {
+	p, err := repositoryURLPath(s)
+	if err != nil || len(p) != 2 || !ValidOwner(p[0]) || !ValidRepository(p[1]) {
+		return "", "", false
+	}
+	return p[0], p[1], true
+}
+
+// repositoryURLPath returns the segments of the path of a git url
+func repositoryURLPath(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	var path string
+	if i := strings.Index(s, "://"); i != -1 {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		path = u.Path
+	} else if i := strings.Index(s, ":"); i != -1 {
+		// scp like syntax: git@github.com:owner/repo.git
+		path = s[i+1:]
+	} else {
+		return nil, fmt.Errorf("invalid repository url `%s'", s)
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return strings.Split(path, "/"), nil
+}
+
+// parsePullRequestURL parses urls like https://github.com/owner/repo/pull/123
+func parsePullRequestURL(s string) (owner, repo string, number int, err error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return "", "", 0, err
+	}
+	p := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(p) < 4 || p[len(p)-2] != "pull" {
+		return "", "", 0, fmt.Errorf("invalid pull request url `%s'", s)
+	}
+	number, err = strconv.Atoi(p[len(p)-1])
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid pull request url `%s'", s)
+	}
+	return p[len(p)-4], p[len(p)-3], number, nil
+}
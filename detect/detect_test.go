@@ -0,0 +1,92 @@
+package detect
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureEnvironment reads the environment variables from the env file of a fixture directory,
+// files are read relative to the directory
+type fixtureEnvironment struct {
+	dir string
+	env map[string]string
+}
+
+func loadFixture(t *testing.T, dir string) *fixtureEnvironment {
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "env"))
+	require.NoError(t, err)
+	env := fixtureEnvironment{dir: dir, env: map[string]string{}}
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		p := strings.SplitN(scanner.Text(), "=", 2)
+		if len(p) == 2 {
+			env.env[p[0]] = p[1]
+		}
+	}
+	return &env
+}
+
+func (e *fixtureEnvironment) Getenv(key string) string {
+	return e.env[key]
+}
+
+func (e *fixtureEnvironment) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(e.dir, name))
+}
+
+func TestDetect(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "*"))
+	require.NoError(t, err)
+	require.NotEmpty(t, dirs)
+
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			buf, err := ioutil.ReadFile(filepath.Join(dir, "expected.json"))
+			require.NoError(t, err)
+			var expected *Target
+			require.NoError(t, json.Unmarshal(buf, &expected))
+
+			target, err := Detect(loadFixture(t, dir))
+			if expected == nil {
+				require.Equal(t, ErrNotDetected, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, expected, target)
+		})
+	}
+}
+
+func TestParseRepositoryURL(t *testing.T) {
+	tests := []struct {
+		Input string
+		Owner string
+		Repo  string
+		Error string
+	}{
+		{"https://github.com/owner/repo.git", "owner", "repo", ""},
+		{"https://github.com/owner/repo", "owner", "repo", ""},
+		{"ssh://git@github.com/owner/repo.git", "owner", "repo", ""},
+		{"git@github.com:owner/repo.git", "owner", "repo", ""},
+		{"owner/repo", "", "", "invalid repository url `owner/repo'"},
+		{"https://github.com/owner", "", "", "invalid repository url `https://github.com/owner'"},
+	}
+
+	for _, test := range tests {
+		owner, repo, err := ParseRepositoryURL(test.Input)
+		if test.Error != "" {
+			require.EqualError(t, err, test.Error)
+		} else {
+			require.NoError(t, err)
+		}
+		require.Equal(t, test.Owner, owner)
+		require.Equal(t, test.Repo, repo)
+	}
+}
@@ -0,0 +1,98 @@
+package detect
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// GitRemote detects the repository from the url of a remote in the git config of a working directory,
+// it does not need the git binary. It is not registered by default, because it cannot detect the pull request.
+type GitRemote struct {
+	// Dir is the directory to start the search for the .git directory, it is searched upwards
+	Dir string
+	// Remote is the name of the remote, defaults to origin
+	Remote string
+}
+
+func (GitRemote) Name() string { return "git-remote" }
+
+func (g GitRemote) Detect(env Environment) (*Target, error) {
+	remote := g.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	config, err := g.readConfig(env)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	u := remoteURL(config, remote)
+	if u == "" {
+		return nil, fmt.Errorf("remote `%s' not found", remote)
+	}
+	var target Target
+	if target.Owner, target.Repository, err = ParseRepositoryURL(u); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// readConfig searches the .git directory (or file) upwards from Dir and returns the content of its config,
+// nil is returned if there is no .git directory
+func (g GitRemote) readConfig(env Environment) ([]byte, error) {
+	dir := filepath.Clean(g.Dir)
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if config, err := env.ReadFile(filepath.Join(gitDir, "config")); err == nil {
+			return config, nil
+		}
+		// worktrees and submodules use a .git file that points to the git directory
+		if buf, err := env.ReadFile(gitDir); err == nil && bytes.HasPrefix(buf, []byte("gitdir:")) {
+			gitDir = strings.TrimSpace(string(buf[len("gitdir:"):]))
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			// worktrees share the config of the main repository
+			if common, err := env.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+				commonDir := strings.TrimSpace(string(common))
+				if !filepath.IsAbs(commonDir) {
+					commonDir = filepath.Join(gitDir, commonDir)
+				}
+				gitDir = commonDir
+			}
+			return env.ReadFile(filepath.Join(gitDir, "config"))
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// remoteURL returns the url of the remote in the git config
+func remoteURL(config []byte, remote string) string {
+	section := fmt.Sprintf(`remote "%s"`, remote)
+	inSection := false
+	scanner := bufio.NewScanner(bytes.NewReader(config))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			inSection = strings.TrimSpace(strings.Trim(line, "[]")) == section
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		p := strings.SplitN(line, "=", 2)
+		if len(p) == 2 && strings.EqualFold(strings.TrimSpace(p[0]), "url") {
+			return strings.Trim(strings.TrimSpace(p[1]), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package detect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fileEnvironment is an environment with in memory files
+type fileEnvironment map[string]string
+
+func (fileEnvironment) Getenv(string) string {
+	return ""
+}
+
+func (e fileEnvironment) ReadFile(name string) ([]byte, error) {
+	s, ok := e[filepath.ToSlash(name)]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(s), nil
+}
+
+const gitConfig = `[core]
+	repositoryformatversion = 0
+	bare = false
+[remote "origin"]
+	url = git@github.com:owner/repo.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+[remote "upstream"]
+	url = https://github.com/upstream/repo.git
+	fetch = +refs/heads/*:refs/remotes/upstream/*
+[branch "master"]
+	remote = origin
+	merge = refs/heads/master
+`
+
+func TestGitRemote(t *testing.T) {
+	env := fileEnvironment{
+		"/src/repo/.git/config":                      gitConfig,
+		"/src/worktree/.git":                         "gitdir: ../repo/.git/worktrees/feature\n",
+		"/src/repo/.git/worktrees/feature/commondir": "../..\n",
+	}
+
+	tests := []struct {
+		Detector GitRemote
+		Owner    string
+		Repo     string
+		Error    string
+	}{
+		{GitRemote{Dir: "/src/repo"}, "owner", "repo", ""},
+		{GitRemote{Dir: "/src/repo/sub/dir"}, "owner", "repo", ""},
+		{GitRemote{Dir: "/src/repo", Remote: "upstream"}, "upstream", "repo", ""},
+		{GitRemote{Dir: "/src/worktree"}, "owner", "repo", ""},
+		{GitRemote{Dir: "/src/repo", Remote: "fork"}, "", "", "remote `fork' not found"},
+	}
+
+	for _, test := range tests {
+		target, err := test.Detector.Detect(env)
+		if test.Error != "" {
+			require.EqualError(t, err, test.Error)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, test.Owner, target.Owner)
+		require.Equal(t, test.Repo, target.Repository)
+	}
+
+	target, err := GitRemote{Dir: "/tmp"}.Detect(env)
+	require.NoError(t, err)
+	require.Nil(t, target)
+}
@@ -0,0 +1,210 @@
+package detect
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+func init() {
+	Register(GithubActions{})
+	Register(Travis{})
+	Register(CircleCI{})
+	Register(Jenkins{})
+	Register(Drone{})
+	Register(Buildkite{})
+	Register(GitlabCI{})
+}
+
+// GithubActions detects GitHub Actions by GITHUB_ACTIONS, GITHUB_REPOSITORY and the event payload in GITHUB_EVENT_PATH
+type GithubActions struct{}
+
+func (GithubActions) Name() string { return "github-actions" }
+
+func (GithubActions) Detect(env Environment) (*Target, error) {
+	if env.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil, nil
+	}
+	var target Target
+	var err error
+	if target.Owner, target.Repository, err = splitSlug(env.Getenv("GITHUB_REPOSITORY")); err != nil {
+		return nil, err
+	}
+	path := env.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return &target, nil
+	}
+	buf, err := env.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var event struct {
+		Number      int `json:"number"`
+		PullRequest struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+		Issue struct {
+			Number int `json:"number"`
+		} `json:"issue"`
+	}
+	if err := json.Unmarshal(buf, &event); err != nil {
+		return nil, fmt.Errorf("unable to parse event payload: %v", err)
+	}
+	switch {
+	case event.PullRequest.Number > 0:
+		target.Number = event.PullRequest.Number
+	case event.Issue.Number > 0:
+		target.Number = event.Issue.Number
+	default:
+		target.Number = event.Number
+	}
+	return &target, nil
+}
+
+// Travis detects Travis CI by TRAVIS, TRAVIS_REPO_SLUG and TRAVIS_PULL_REQUEST
+type Travis struct{}
+
+func (Travis) Name() string { return "travis" }
+
+func (Travis) Detect(env Environment) (*Target, error) {
+	if env.Getenv("TRAVIS") != "true" {
+		return nil, nil
+	}
+	var target Target
+	var err error
+	if target.Owner, target.Repository, err = splitSlug(env.Getenv("TRAVIS_REPO_SLUG")); err != nil {
+		return nil, err
+	}
+	if target.Number, err = parseNumber(env.Getenv("TRAVIS_PULL_REQUEST")); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// CircleCI detects CircleCI by CIRCLECI, CIRCLE_PROJECT_USERNAME, CIRCLE_PROJECT_REPONAME
+// and CIRCLE_PR_NUMBER or CIRCLE_PULL_REQUEST
+type CircleCI struct{}
+
+func (CircleCI) Name() string { return "circleci" }
+
+func (CircleCI) Detect(env Environment) (*Target, error) {
+	if env.Getenv("CIRCLECI") != "true" {
+		return nil, nil
+	}
+	target := Target{
+		Owner:      env.Getenv("CIRCLE_PROJECT_USERNAME"),
+		Repository: env.Getenv("CIRCLE_PROJECT_REPONAME"),
+	}
+	if target.Owner == "" || target.Repository == "" {
+		return nil, fmt.Errorf("CIRCLE_PROJECT_USERNAME and CIRCLE_PROJECT_REPONAME must be set")
+	}
+	var err error
+	if target.Number, err = parseNumber(env.Getenv("CIRCLE_PR_NUMBER")); err != nil {
+		return nil, err
+	}
+	if pr := env.Getenv("CIRCLE_PULL_REQUEST"); target.Number == 0 && pr != "" {
+		if _, _, target.Number, err = parsePullRequestURL(pr); err != nil {
+			return nil, err
+		}
+	}
+	return &target, nil
+}
+
+// Jenkins detects the Jenkins GitHub Branch Source plugin by JENKINS_URL, CHANGE_ID and CHANGE_URL (or GIT_URL),
+// jobs whose GIT_URL cannot belong to github are not detected
+type Jenkins struct{}
+
+func (Jenkins) Name() string { return "jenkins" }
+
+func (Jenkins) Detect(env Environment) (*Target, error) {
+	if env.Getenv("JENKINS_URL") == "" {
+		return nil, nil
+	}
+	var target Target
+	var err error
+	if change := env.Getenv("CHANGE_URL"); change != "" {
+		if target.Owner, target.Repository, target.Number, err = parsePullRequestURL(change); err != nil {
+			return nil, err
+		}
+		return &target, nil
+	}
+	var ok bool
+	if target.Owner, target.Repository, ok = parseGithubRepositoryURL(env.Getenv("GIT_URL")); !ok {
+		// the job does not build a github repository
+		return nil, nil
+	}
+	if target.Number, err = parseNumber(env.Getenv("CHANGE_ID")); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// Drone detects Drone by DRONE, DRONE_REPO (or DRONE_REPO_OWNER and DRONE_REPO_NAME) and DRONE_PULL_REQUEST
+type Drone struct{}
+
+func (Drone) Name() string { return "drone" }
+
+func (Drone) Detect(env Environment) (*Target, error) {
+	if env.Getenv("DRONE") != "true" {
+		return nil, nil
+	}
+	target := Target{
+		Owner:      env.Getenv("DRONE_REPO_OWNER"),
+		Repository: env.Getenv("DRONE_REPO_NAME"),
+	}
+	var err error
+	if target.Owner == "" || target.Repository == "" {
+		if target.Owner, target.Repository, err = splitSlug(env.Getenv("DRONE_REPO")); err != nil {
+			return nil, err
+		}
+	}
+	if target.Number, err = parseNumber(env.Getenv("DRONE_PULL_REQUEST")); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// Buildkite detects Buildkite by BUILDKITE, BUILDKITE_REPO and BUILDKITE_PULL_REQUEST
+type Buildkite struct{}
+
+func (Buildkite) Name() string { return "buildkite" }
+
+func (Buildkite) Detect(env Environment) (*Target, error) {
+	if env.Getenv("BUILDKITE") != "true" {
+		return nil, nil
+	}
+	var target Target
+	var err error
+	if target.Owner, target.Repository, err = ParseRepositoryURL(env.Getenv("BUILDKITE_REPO")); err != nil {
+		return nil, err
+	}
+	if target.Number, err = parseNumber(env.Getenv("BUILDKITE_PULL_REQUEST")); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// GitlabCI detects GitLab CI/CD for external (GitHub) repositories by GITLAB_CI,
+// CI_EXTERNAL_PULL_REQUEST_TARGET_REPOSITORY (or CI_PROJECT_PATH) and CI_EXTERNAL_PULL_REQUEST_IID
+type GitlabCI struct{}
+
+func (GitlabCI) Name() string { return "gitlab-ci" }
+
+func (GitlabCI) Detect(env Environment) (*Target, error) {
+	if env.Getenv("GITLAB_CI") != "true" {
+		return nil, nil
+	}
+	var target Target
+	var err error
+	if slug := env.Getenv("CI_EXTERNAL_PULL_REQUEST_TARGET_REPOSITORY"); slug != "" {
+		if target.Owner, target.Repository, err = splitSlug(slug); err != nil {
+			return nil, err
+		}
+	} else if target.Owner, target.Repository, err = splitSlug(env.Getenv("CI_PROJECT_PATH")); err != nil {
+		// projects in subgroups (group/subgroup/repo) cannot be github repositories
+		return nil, nil
+	}
+	if target.Number, err = parseNumber(env.Getenv("CI_EXTERNAL_PULL_REQUEST_IID")); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
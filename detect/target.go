@@ -0,0 +1,130 @@
+package detect
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ValidOwner reports whether s is a valid user or organization name:
+// up to 39 letters, numbers, hyphens or underscores (used by enterprise managed users like jdoe_acme)
+// that do not start or end with a hyphen
+func ValidOwner(s string) bool {
+	if s == "" || len(s) > 39 || s[0] == '-' || s[len(s)-1] == '-' {
+		return false
+	}
+	for _, r := range s {
+		if !isASCIIAlnum(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidRepository reports whether s is a valid repository name:
+// up to 100 letters, numbers, hyphens, underscores or dots
+func ValidRepository(s string) bool {
+	if s == "" || len(s) > 100 || s == "." || s == ".." {
+		return false
+	}
+	for _, r := range s {
+		if !isASCIIAlnum(r) && r != '-' && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIAlnum(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// ParseTarget parses a target that was specified by the user, these forms are supported:
+//
+//	owner/repo#2
+//	https://github.com/owner/repo/pull/2
+//	https://github.com/owner/repo/issues/5#issuecomment-123
+//	https://github.example.com/owner/repo/pull/2 (github enterprise)
+func ParseTarget(s string) (*Target, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "://") {
+		return parseTargetURL(s)
+	}
+
+	target := Target{}
+	slug := s
+	if i := strings.Index(s, "#"); i != -1 {
+		slug = s[:i]
+		number, err := strconv.Atoi(s[i+1:])
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("invalid number in target `%s'", s)
+		}
+		target.Number = number
+	}
+	if err := setRepository(&target, strings.Split(slug, "/"), s); err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+// parseTargetURL parses issue, pull request and comment urls
+func parseTargetURL(s string) (*Target, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("unsupported scheme in target `%s'", s)
+	}
+
+	target := Target{}
+	if host := strings.ToLower(u.Host); host != "github.com" && host != "www.github.com" {
+		target.Host = u.Host
+	}
+
+	p := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(p) < 2 {
+		return nil, fmt.Errorf("invalid repository in target `%s'", s)
+	}
+	p[1] = strings.TrimSuffix(p[1], ".git")
+	if err := setRepository(&target, p[:2], s); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(p) == 2:
+	case len(p) >= 4 && (p[2] == "pull" || p[2] == "issues"):
+		// trailing parts like /files or /commits are ignored
+		target.Number, err = strconv.Atoi(p[3])
+		if err != nil || target.Number <= 0 {
+			return nil, fmt.Errorf("invalid number in target `%s'", s)
+		}
+	default:
+		return nil, fmt.Errorf("target `%s' is not an issue or a pull request", s)
+	}
+
+	if u.Fragment != "" && strings.HasPrefix(u.Fragment, "issuecomment-") {
+		target.CommentID, err = strconv.ParseInt(strings.TrimPrefix(u.Fragment, "issuecomment-"), 10, 64)
+		if err != nil || target.CommentID <= 0 {
+			return nil, fmt.Errorf("invalid comment in target `%s'", s)
+		}
+	}
+	return &target, nil
+}
+
+// setRepository validates owner and repository and sets them on the target
+func setRepository(target *Target, p []string, s string) error {
+	if len(p) != 2 {
+		return fmt.Errorf("invalid repository in target `%s'", s)
+	}
+	if !ValidOwner(p[0]) {
+		return fmt.Errorf("invalid owner `%s' in target `%s'", p[0], s)
+	}
+	if !ValidRepository(p[1]) {
+		return fmt.Errorf("invalid repository name `%s' in target `%s'", p[1], s)
+	}
+	target.Owner = p[0]
+	target.Repository = p[1]
+	return nil
+}
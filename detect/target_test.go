@@ -0,0 +1,57 @@
+package detect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		Input  string
+		Target *Target
+		Error  string
+	}{
+		{"owner/repo#2", &Target{Owner: "owner", Repository: "repo", Number: 2}, ""},
+		{"owner/repo", &Target{Owner: "owner", Repository: "repo"}, ""},
+		{"https://github.com/owner/repo/pull/2", &Target{Owner: "owner", Repository: "repo", Number: 2}, ""},
+		{"https://github.com/owner/repo/pull/2/files", &Target{Owner: "owner", Repository: "repo", Number: 2}, ""},
+		{"https://github.com/owner/repo/issues/5#issuecomment-123", &Target{Owner: "owner", Repository: "repo", Number: 5, CommentID: 123}, ""},
+		{"https://github.com/owner/repo/pull/2#discussion_r1", &Target{Owner: "owner", Repository: "repo", Number: 2}, ""},
+		{"https://github.example.com/my-org/repo.js/pull/7", &Target{Host: "github.example.com", Owner: "my-org", Repository: "repo.js", Number: 7}, ""},
+
+		{"bob/repo1/repo2", nil, "invalid repository in target `bob/repo1/repo2'"},
+		{"owner/repo#x", nil, "invalid number in target `owner/repo#x'"},
+		{"-owner/repo#2", nil, "invalid owner `-owner' in target `-owner/repo#2'"},
+		{"owner/re po#2", nil, "invalid repository name `re po' in target `owner/re po#2'"},
+		{"owner/..", nil, "invalid repository name `..' in target `owner/..'"},
+		{"https://github.com/owner", nil, "invalid repository in target `https://github.com/owner'"},
+		{"https://github.com/owner/repo/tree/master", nil, "target `https://github.com/owner/repo/tree/master' is not an issue or a pull request"},
+		{"https://github.com/owner/repo/issues/5#issuecomment-x", nil, "invalid comment in target `https://github.com/owner/repo/issues/5#issuecomment-x'"},
+		{"ftp://github.com/owner/repo", nil, "unsupported scheme in target `ftp://github.com/owner/repo'"},
+	}
+
+	for _, test := range tests {
+		target, err := ParseTarget(test.Input)
+		if test.Error != "" {
+			require.EqualError(t, err, test.Error, test.Input)
+		} else {
+			require.NoError(t, err, test.Input)
+		}
+		require.Equal(t, test.Target, target, test.Input)
+	}
+}
+
+func TestValidOwnerAndRepository(t *testing.T) {
+	require.True(t, ValidOwner("Eun"))
+	require.True(t, ValidOwner("my-org"))
+	require.True(t, ValidOwner("jdoe_acme"))
+	require.False(t, ValidOwner("my.org"))
+	require.False(t, ValidOwner("org-"))
+	require.False(t, ValidOwner("abcdefghijklmnopqrstuvwxyzabcdefghijklmn"))
+
+	require.True(t, ValidRepository("github-comment"))
+	require.True(t, ValidRepository(".github"))
+	require.False(t, ValidRepository("repo1/repo2"))
+	require.False(t, ValidRepository("."))
+}
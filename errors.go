@@ -0,0 +1,209 @@
+package githubcomment
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// The api errors are returned by all functions that talk to github, they wrap the error of the client.
+// Test for a class with errors.Is (e.g. errors.Is(err, NotFoundError{})) or use errors.As to get the details,
+// the errors of this package (like IssueCommentNotFoundError) can be retrieved with errors.As as their own type
+// and as their class (the Err of the class is the error then).
+
+// NotFoundError is returned if github responds with 404, the repository, issue or comment does not exist
+// (or the token has no access to it)
+type NotFoundError struct {
+	Err error
+}
+
+func (e NotFoundError) Error() string { return e.Err.Error() }
+func (e NotFoundError) Unwrap() error { return e.Err }
+func (e NotFoundError) Is(target error) bool {
+	_, ok := target.(NotFoundError)
+	return ok
+}
+
+// UnauthorizedError is returned if github responds with 401, the token is missing, invalid or expired
+type UnauthorizedError struct {
+	Err error
+}
+
+func (e UnauthorizedError) Error() string { return e.Err.Error() }
+func (e UnauthorizedError) Unwrap() error { return e.Err }
+func (e UnauthorizedError) Is(target error) bool {
+	_, ok := target.(UnauthorizedError)
+	return ok
+}
+
+// ForbiddenError is returned if github responds with 403 (and it is not a rate limit), the token lacks permissions
+type ForbiddenError struct {
+	Err error
+}
+
+func (e ForbiddenError) Error() string { return e.Err.Error() }
+func (e ForbiddenError) Unwrap() error { return e.Err }
+func (e ForbiddenError) Is(target error) bool {
+	_, ok := target.(ForbiddenError)
+	return ok
+}
+
+// RateLimitedError is returned if the primary or secondary rate limit was hit
+type RateLimitedError struct {
+	Err error
+	// Reset is the time the rate limit resets, zero if unknown
+	Reset time.Time
+}
+
+func (e RateLimitedError) Error() string { return e.Err.Error() }
+func (e RateLimitedError) Unwrap() error { return e.Err }
+func (e RateLimitedError) Is(target error) bool {
+	_, ok := target.(RateLimitedError)
+	return ok
+}
+
+// ValidationError is returned if github responds with 422 or the input is invalid
+type ValidationError struct {
+	Err error
+}
+
+func (e ValidationError) Error() string { return e.Err.Error() }
+func (e ValidationError) Unwrap() error { return e.Err }
+func (e ValidationError) Is(target error) bool {
+	_, ok := target.(ValidationError)
+	return ok
+}
+
+// ConflictError is returned if github responds with 409 or the comments are in a state that prevents the operation
+type ConflictError struct {
+	Err error
+}
+
+func (e ConflictError) Error() string { return e.Err.Error() }
+func (e ConflictError) Unwrap() error { return e.Err }
+func (e ConflictError) Is(target error) bool {
+	_, ok := target.(ConflictError)
+	return ok
+}
+
+// The errors of this package belong to the classes above.
+
+// asClass sets target to the class of err if target points to it,
+// so errors.As finds the class of the errors of this package
+func asClass(err error, target interface{}) bool {
+	switch t := target.(type) {
+	case *NotFoundError:
+		if errors.Is(err, NotFoundError{}) {
+			*t = NotFoundError{Err: err}
+			return true
+		}
+	case *UnauthorizedError:
+		if errors.Is(err, UnauthorizedError{}) {
+			*t = UnauthorizedError{Err: err}
+			return true
+		}
+	case *ForbiddenError:
+		if errors.Is(err, ForbiddenError{}) {
+			*t = ForbiddenError{Err: err}
+			return true
+		}
+	case *ValidationError:
+		if errors.Is(err, ValidationError{}) {
+			*t = ValidationError{Err: err}
+			return true
+		}
+	case *ConflictError:
+		if errors.Is(err, ConflictError{}) {
+			*t = ConflictError{Err: err}
+			return true
+		}
+	}
+	return false
+}
+
+func (e IssueCommentNotFoundError) Is(target error) bool {
+	_, ok := target.(NotFoundError)
+	return ok
+}
+func (e IssueCommentNotFoundError) As(target interface{}) bool { return asClass(e, target) }
+
+func (e IDMustBeSpecifiedError) Is(target error) bool {
+	_, ok := target.(ValidationError)
+	return ok
+}
+func (e IDMustBeSpecifiedError) As(target interface{}) bool { return asClass(e, target) }
+
+func (e IDCollisionError) Is(target error) bool {
+	_, ok := target.(ConflictError)
+	return ok
+}
+func (e IDCollisionError) As(target interface{}) bool { return asClass(e, target) }
+
+func (e IDMismatchError) Is(target error) bool {
+	_, ok := target.(ConflictError)
+	return ok
+}
+func (e IDMismatchError) As(target interface{}) bool { return asClass(e, target) }
+
+func (e IssueBodyNotDeletableError) Is(target error) bool {
+	_, ok := target.(ValidationError)
+	return ok
+}
+func (e IssueBodyNotDeletableError) As(target interface{}) bool { return asClass(e, target) }
+
+func (e UntrustedAuthorError) Is(target error) bool {
+	_, ok := target.(ForbiddenError)
+	return ok
+}
+func (e UntrustedAuthorError) As(target interface{}) bool { return asClass(e, target) }
+
+func (e UnverifiedCommentError) Is(target error) bool {
+	_, ok := target.(ForbiddenError)
+	return ok
+}
+func (e UnverifiedCommentError) As(target interface{}) bool { return asClass(e, target) }
+
+func (e MetaValidationError) Is(target error) bool {
+	_, ok := target.(ValidationError)
+	return ok
+}
+func (e MetaValidationError) As(target interface{}) bool { return asClass(e, target) }
+
+// apiError wraps an error of the client in the error of its class, other errors are returned unchanged
+func apiError(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *github.RateLimitError:
+		return RateLimitedError{Err: err, Reset: e.Rate.Reset.Time}
+	case *github.AbuseRateLimitError:
+		reset := time.Time{}
+		if e.RetryAfter != nil {
+			reset = time.Now().Add(*e.RetryAfter)
+		}
+		return RateLimitedError{Err: err, Reset: reset}
+	case *github.TwoFactorAuthError:
+		return UnauthorizedError{Err: err}
+	case *github.ErrorResponse:
+		if e.Response == nil {
+			return err
+		}
+		switch e.Response.StatusCode {
+		case http.StatusNotFound:
+			return NotFoundError{Err: err}
+		case http.StatusUnauthorized:
+			return UnauthorizedError{Err: err}
+		case http.StatusForbidden:
+			return ForbiddenError{Err: err}
+		case http.StatusTooManyRequests:
+			return RateLimitedError{Err: err}
+		case http.StatusUnprocessableEntity:
+			return ValidationError{Err: err}
+		case http.StatusConflict:
+			return ConflictError{Err: err}
+		}
+	}
+	return err
+}
@@ -0,0 +1,79 @@
+package githubcomment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		Status int
+		Header map[string]string
+		Body   string
+		Class  error
+	}{
+		{http.StatusNotFound, nil, `{"message":"Not Found"}`, NotFoundError{}},
+		{http.StatusUnauthorized, nil, `{"message":"Bad credentials"}`, UnauthorizedError{}},
+		{http.StatusForbidden, nil, `{"message":"Resource not accessible by integration"}`, ForbiddenError{}},
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000000"}, `{"message":"API rate limit exceeded for 127.0.0.1."}`, RateLimitedError{}},
+		{http.StatusTooManyRequests, nil, `{"message":"Too Many Requests"}`, RateLimitedError{}},
+		{http.StatusUnprocessableEntity, nil, `{"message":"Validation Failed"}`, ValidationError{}},
+		{http.StatusConflict, nil, `{"message":"Conflict"}`, ConflictError{}},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for key, value := range test.Header {
+				w.Header().Set(key, value)
+			}
+			w.WriteHeader(test.Status)
+			w.Write([]byte(test.Body))
+		}))
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		gc := &GithubComment{Client: client, Context: context.Background(), Owner: "owner", Repository: "repo"}
+
+		_, err := gc.GetIssueComment(1, ID("build"))
+		server.Close()
+		require.True(t, errors.Is(err, test.Class), "%d: %T", test.Status, err)
+
+		var errorResponse *github.ErrorResponse
+		var rateLimitError *github.RateLimitError
+		require.True(t, errors.As(err, &errorResponse) || errors.As(err, &rateLimitError), "%d: %T", test.Status, err)
+	}
+}
+
+func TestErrorClasses(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	f.addComment("bot", "hello")
+
+	_, err := gc.GetIssueComment(1, ID("build"))
+	require.True(t, errors.Is(err, NotFoundError{}))
+	var notFound IssueCommentNotFoundError
+	require.True(t, errors.As(err, &notFound))
+	require.Equal(t, ID("build"), notFound.ID)
+
+	// the class can be retrieved with errors.As as well
+	var class NotFoundError
+	require.True(t, errors.As(err, &class))
+	require.Equal(t, IssueCommentNotFoundError{ID: ID("build")}, class.Err)
+	require.False(t, errors.As(err, &ValidationError{}))
+
+	_, err = gc.GetIssueComment(1, ID(""))
+	require.True(t, errors.Is(err, ValidationError{}))
+	require.False(t, errors.Is(err, NotFoundError{}))
+	require.True(t, errors.As(err, &ValidationError{}))
+	require.True(t, errors.As(fmt.Errorf("wrapped: %w", UntrustedAuthorError{CommentID: 1}), &ForbiddenError{}))
+
+	_, err = gc.GetIssueComment(2, ID("build"))
+	require.True(t, errors.Is(err, NotFoundError{}))
+	var e NotFoundError
+	require.True(t, errors.As(err, &e))
+}
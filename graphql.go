@@ -0,0 +1,61 @@
+package githubcomment
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e GraphQLError) Error() string {
+	return fmt.Sprintf("graphql request failed: %s", strings.Join(e.Messages, ", "))
+}
+
+// graphQLURL returns the GraphQLURL or derives it from the base url of the client
+func (gc *GithubComment) graphQLURL() string {
+	if gc.GraphQLURL != "" {
+		return gc.GraphQLURL
+	}
+	u := *gc.Client.BaseURL
+	// enterprise servers serve the rest api at /api/v3/ and graphql at /api/graphql
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/graphql"
+	return u.String()
+}
+
+// graphQL runs the query and decodes the data of the response into v
+func (gc *GithubComment) graphQL(query string, variables map[string]interface{}, v interface{}) error {
+	req, err := gc.Client.NewRequest("POST", gc.graphQLURL(), map[string]interface{}{
+		"query":     query,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+	var res struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err = gc.Client.Do(gc.Context, req, &res); err != nil {
+		return apiError(err)
+	}
+	if len(res.Errors) > 0 {
+		var e GraphQLError
+		for _, m := range res.Errors {
+			e.Messages = append(e.Messages, m.Message)
+		}
+		return e
+	}
+	if v == nil || len(res.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(res.Data, v)
+}
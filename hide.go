@@ -0,0 +1,147 @@
+package githubcomment
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/github"
+)
+
+// MinimizeReason is the reason that is shown for a minimized comment
+type MinimizeReason string
+
+const (
+	MinimizeOutdated  MinimizeReason = "OUTDATED"
+	MinimizeResolved  MinimizeReason = "RESOLVED"
+	MinimizeDuplicate MinimizeReason = "DUPLICATE"
+	MinimizeOffTopic  MinimizeReason = "OFF_TOPIC"
+	MinimizeSpam      MinimizeReason = "SPAM"
+	MinimizeAbuse     MinimizeReason = "ABUSE"
+)
+
+const minimizeCommentMutation = `mutation($id: ID!, $classifier: ReportedContentClassifiers!) {
+  minimizeComment(input: {subjectId: $id, classifier: $classifier}) {
+    minimizedComment { isMinimized }
+  }
+}`
+
+// MinimizeIssueComment minimizes (hides) a comment, in a dry run nothing happens
+func (gc *GithubComment) MinimizeIssueComment(comment *github.IssueComment, reason MinimizeReason) error {
+	if comment.GetNodeID() == "" {
+		return fmt.Errorf("comment %d has no node id", comment.GetID())
+	}
+	if gc.DryRun {
+		return nil
+	}
+	var data struct {
+		MinimizeComment struct {
+			MinimizedComment struct {
+				IsMinimized bool `json:"isMinimized"`
+			} `json:"minimizedComment"`
+		} `json:"minimizeComment"`
+	}
+	if err := gc.graphQL(minimizeCommentMutation, map[string]interface{}{
+		"id":         comment.GetNodeID(),
+		"classifier": string(reason),
+	}, &data); err != nil {
+		return err
+	}
+	if !data.MinimizeComment.MinimizedComment.IsMinimized {
+		return fmt.Errorf("comment %d was not minimized", comment.GetID())
+	}
+	return nil
+}
+
+// HideIssueComments minimizes all comments with the id as outdated and returns the ids of the hidden comments
+func (gc *GithubComment) HideIssueComments(issueID int, id ID) ([]int64, error) {
+	comments, err := gc.FindIssueComments(issueID, id)
+	if err != nil {
+		return nil, err
+	}
+	return gc.hideIssueComments(comments, 0)
+}
+
+// PostAndHideIssueComment posts a new comment and minimizes all earlier comments with the same id as outdated
+func (gc *GithubComment) PostAndHideIssueComment(issueID int, id ID, text string, meta interface{}) (*Result, error) {
+	if id == "" {
+		return nil, IDMustBeSpecifiedError{}
+	}
+	previous, err := gc.FindIssueComments(issueID, id)
+	if err != nil {
+		return nil, err
+	}
+	result, err := gc.PostIssueComment(issueID, id, text, meta)
+	if err != nil {
+		return nil, err
+	}
+	result.Hidden, err = gc.hideIssueComments(previous, result.CommentID)
+	return result, err
+}
+
+const minimizedCommentsQuery = `query($ids: [ID!]!) {
+  nodes(ids: $ids) {
+    ... on IssueComment { id isMinimized }
+  }
+}`
+
+// minimizedComments returns the node ids of the comments that are already minimized,
+// the rest api does not tell so they are looked up with graphql (in pages of 100 nodes)
+func (gc *GithubComment) minimizedComments(comments []*github.IssueComment) (map[string]bool, error) {
+	minimized := map[string]bool{}
+	var ids []string
+	for _, comment := range comments {
+		if comment.GetNodeID() != "" {
+			ids = append(ids, comment.GetNodeID())
+		}
+	}
+	for len(ids) > 0 {
+		page := ids
+		if len(page) > 100 {
+			page = page[:100]
+		}
+		ids = ids[len(page):]
+		var data struct {
+			Nodes []struct {
+				ID          string `json:"id"`
+				IsMinimized bool   `json:"isMinimized"`
+			} `json:"nodes"`
+		}
+		if err := gc.graphQL(minimizedCommentsQuery, map[string]interface{}{"ids": page}, &data); err != nil {
+			return nil, err
+		}
+		for _, node := range data.Nodes {
+			if node.IsMinimized {
+				minimized[node.ID] = true
+			}
+		}
+	}
+	return minimized, nil
+}
+
+// hideIssueComments minimizes the comments (except the one with the except id),
+// comments that are already minimized are skipped and not reported again
+func (gc *GithubComment) hideIssueComments(comments []*github.IssueComment, except int64) ([]int64, error) {
+	var candidates []*github.IssueComment
+	for _, comment := range comments {
+		if comment.GetID() != except {
+			candidates = append(candidates, comment)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	minimized, err := gc.minimizedComments(candidates)
+	if err != nil {
+		return nil, err
+	}
+	var hidden []int64
+	for _, comment := range candidates {
+		if minimized[comment.GetNodeID()] {
+			continue
+		}
+		if err := gc.MinimizeIssueComment(comment, MinimizeOutdated); err != nil {
+			return hidden, err
+		}
+		hidden = append(hidden, comment.GetID())
+	}
+	return hidden, nil
+}
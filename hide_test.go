@@ -0,0 +1,88 @@
+package githubcomment
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostAndHideIssueComment(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	first, err := gc.PostIssueComment(1, ID("build"), "first", nil)
+	require.NoError(t, err)
+	other, err := gc.PostIssueComment(1, ID("other"), "other", nil)
+	require.NoError(t, err)
+
+	second, err := gc.PostAndHideIssueComment(1, ID("build"), "second", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionCreated, second.Action)
+	require.Equal(t, []int64{first.CommentID}, second.Hidden)
+
+	third, err := gc.PostAndHideIssueComment(1, ID("build"), "third", nil)
+	require.NoError(t, err)
+	// the first comment is already hidden
+	require.Equal(t, []int64{second.CommentID}, third.Hidden)
+
+	require.Equal(t, map[string]string{
+		"IC_101": "OUTDATED",
+		"IC_103": "OUTDATED",
+	}, f.minimized)
+	require.Equal(t, int64(102), other.CommentID)
+
+	hidden, err := gc.HideIssueComments(1, ID("other"))
+	require.NoError(t, err)
+	require.Equal(t, []int64{other.CommentID}, hidden)
+
+	// hidden comments are not hidden again
+	hidden, err = gc.HideIssueComments(1, ID("other"))
+	require.NoError(t, err)
+	require.Empty(t, hidden)
+
+	fourth, err := gc.PostAndHideIssueComment(1, ID("build"), "fourth", nil)
+	require.NoError(t, err)
+	require.Equal(t, []int64{third.CommentID}, fourth.Hidden)
+}
+
+func TestGetAndUpdateAfterHidePrevious(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	_, err := gc.PostAndHideIssueComment(1, ID("build"), "first", map[string]interface{}{"run": 1})
+	require.NoError(t, err)
+	second, err := gc.PostAndHideIssueComment(1, ID("build"), "second", map[string]interface{}{"run": 2})
+	require.NoError(t, err)
+
+	// the newest comment is used, not the hidden one
+	info, err := gc.GetIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.Equal(t, second.CommentID, info.CommentID)
+	require.Equal(t, "second", info.Body)
+	require.Equal(t, map[string]interface{}{"run": float64(2)}, info.Meta)
+
+	result, err := gc.UpdateIssueComment(1, ID("build"), "third", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+	require.Equal(t, second.CommentID, result.CommentID)
+	require.Contains(t, f.comments[0].GetBody(), "first")
+}
+
+func TestMinimizeIssueCommentError(t *testing.T) {
+	_, gc := newFakeGithub(t, "")
+	err := gc.MinimizeIssueComment(&github.IssueComment{ID: github.Int64(1), NodeID: github.String("unknown")}, MinimizeOutdated)
+	require.Equal(t, GraphQLError{Messages: []string{"Could not resolve to a node with the global id of 'unknown'"}}, err)
+}
+
+func TestGraphQLURL(t *testing.T) {
+	tests := []struct {
+		BaseURL string
+		URL     string
+	}{
+		{"https://api.github.com/", "https://api.github.com/graphql"},
+		{"https://github.example.com/api/v3/", "https://github.example.com/api/graphql"},
+	}
+	for _, test := range tests {
+		gc := GithubComment{Client: github.NewClient(nil)}
+		gc.Client.BaseURL, _ = url.Parse(test.BaseURL)
+		require.Equal(t, test.URL, gc.graphQLURL())
+	}
+}
@@ -0,0 +1,100 @@
+package githubcomment
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxBodyLength is the maximum length of a comment body that github accepts
+const MaxBodyLength = 65536
+
+const historyMagic = "github-info-history"
+
+var historyMarker = fmt.Sprintf("<!---%s--->", historyMagic)
+var historyEntryMarker = fmt.Sprintf("<!---%s-entry--->", historyMagic)
+
+// HistoryEntry is a previous body of a comment
+type HistoryEntry struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// buildHistory renders the history entries as collapsible sections
+func buildHistory(entries []HistoryEntry) string {
+	var sb strings.Builder
+	sb.WriteString("\n\n")
+	sb.WriteString(historyMarker)
+	for _, entry := range entries {
+		sb.WriteRune('\n')
+		sb.WriteString(historyEntryMarker)
+		sb.WriteString("\n<details>\n<summary>")
+		sb.WriteString(html.EscapeString(entry.Title))
+		sb.WriteString("</summary>\n\n")
+		sb.WriteString(entry.Body)
+		sb.WriteString("\n\n</details>")
+	}
+	return sb.String()
+}
+
+// parseHistory splits the body into the current body and the history entries,
+// if the history is malformed the body is returned as it is
+func parseHistory(body string) (string, []HistoryEntry) {
+	start := strings.Index(body, "\n\n"+historyMarker)
+	if start == -1 {
+		return body, nil
+	}
+	parts := strings.Split(body[start+2+len(historyMarker):], "\n"+historyEntryMarker+"\n")
+	if parts[0] != "" {
+		return body, nil
+	}
+	entries := make([]HistoryEntry, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		if !strings.HasPrefix(part, "<details>\n<summary>") || !strings.HasSuffix(part, "\n\n</details>") {
+			return body, nil
+		}
+		part = strings.TrimSuffix(strings.TrimPrefix(part, "<details>\n<summary>"), "\n\n</details>")
+		end := strings.Index(part, "</summary>\n\n")
+		if end == -1 {
+			return body, nil
+		}
+		entries = append(entries, HistoryEntry{
+			Title: html.UnescapeString(part[:end]),
+			Body:  part[end+len("</summary>\n\n"):],
+		})
+	}
+	return body[:start], entries
+}
+
+// archive carries the history of the previous version over to the info and,
+// if KeepHistory is set, adds the previous body as the newest entry
+func (gc *GithubComment) archive(raw string, updatedAt *time.Time, info *Info) {
+	previous, err := ParseInfo(raw)
+	if err != nil {
+		return
+	}
+	info.History = previous.History
+	if gc.KeepHistory <= 0 {
+		return
+	}
+	if previous.Body != info.Body {
+		title := time.Now()
+		if updatedAt != nil {
+			title = *updatedAt
+		}
+		info.History = append([]HistoryEntry{{
+			Title: title.UTC().Format(time.RFC3339),
+			Body:  previous.Body,
+		}}, previous.History...)
+	}
+	if len(info.History) > gc.KeepHistory {
+		info.History = info.History[:gc.KeepHistory]
+	}
+}
+
+// fitsBody reports whether the raw body (plus reserve characters) is short enough for github
+func fitsBody(raw string, reserve int) bool {
+	return utf8.RuneCountInString(raw)+reserve <= MaxBodyLength
+}
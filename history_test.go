@@ -0,0 +1,71 @@
+package githubcomment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHistory(t *testing.T) {
+	entries := []HistoryEntry{
+		{Title: "2019-01-02T00:00:00Z", Body: "second\n\n</details>"},
+		{Title: "<first>", Body: "first"},
+	}
+	body, history := parseHistory("current" + buildHistory(entries))
+	require.Equal(t, "current", body)
+	require.Equal(t, entries, history)
+
+	body, history = parseHistory("current")
+	require.Equal(t, "current", body)
+	require.Nil(t, history)
+
+	malformed := "current\n\n" + historyMarker + "\nsomething"
+	body, history = parseHistory(malformed)
+	require.Equal(t, malformed, body)
+	require.Nil(t, history)
+}
+
+func TestKeepHistory(t *testing.T) {
+	_, gc := newFakeGithub(t, "")
+	gc.KeepHistory = 2
+
+	for _, text := range []string{"first", "second", "second", "third", "fourth"} {
+		_, err := gc.UpdateIssueComment(1, ID("build"), text, nil)
+		require.NoError(t, err)
+	}
+
+	info, err := gc.GetIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.Equal(t, "fourth", info.Body)
+	require.Len(t, info.History, 2)
+	require.Equal(t, "third", info.History[0].Body)
+	require.Equal(t, "second", info.History[1].Body)
+
+	// the history is kept when only the meta changes
+	_, err = gc.SetIssueCommentMeta(1, ID("build"), map[string]interface{}{"a": 1})
+	require.NoError(t, err)
+	info, err = gc.GetIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.Len(t, info.History, 2)
+}
+
+func TestKeepHistorySizeLimit(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.KeepHistory = 5
+	gc.SigningKey = []byte("secret")
+
+	large := strings.Repeat("a", MaxBodyLength/3)
+	for _, text := range []string{large + "1", large + "2", large + "3", large + "4"} {
+		_, err := gc.UpdateIssueComment(1, ID("build"), text, nil)
+		require.NoError(t, err)
+	}
+	require.True(t, fitsBody(f.comments[0].GetBody(), 0))
+
+	info, err := gc.GetIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.True(t, info.Verified)
+	require.Equal(t, large+"4", info.Body)
+	require.Len(t, info.History, 1)
+	require.Equal(t, large+"3", info.History[0].Body)
+}
@@ -3,6 +3,7 @@ package githubcomment
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/google/go-github/github"
@@ -13,6 +14,32 @@ type GithubComment struct {
 	Context    context.Context
 	Owner      string
 	Repository string
+	// MetaSchema is used to validate the meta before writing and after reading a comment (optional)
+	MetaSchema *Schema
+	// MetaKeys are used to encrypt and decrypt the meta (optional),
+	// the first key is used for encryption, all keys are used for decryption
+	MetaKeys []MetaKey
+	// SigningKey is used to sign comments with an hmac (optional)
+	SigningKey []byte
+	// SkipUnverified makes lookups skip comments whose signature cannot be verified with the SigningKey,
+	// otherwise they are returned with Info.Verified set to false
+	SkipUnverified bool
+	// TrustedAuthors limits lookups and updates to comments written by these logins (optional),
+	// use Me for the authenticated user
+	TrustedAuthors []string
+	// KeepHistory is the number of previous bodies that are kept in a collapsible section when a comment is updated
+	KeepHistory int
+	// StickyBottom makes updates delete and post the comment again if other comments were posted after it,
+	// so it stays the newest comment
+	StickyBottom bool
+	// GraphQLURL is the url of the graphql api (optional), by default it is derived from the client
+	GraphQLURL string
+	// DryRun makes all functions only read, the results describe what would have been written
+	DryRun bool
+	// MigrateLegacyMarkers makes lookups fall back to the markers of earlier versions.
+	// A legacy marker is identical to the marker of the id without the stripped characters
+	// (ci/lint and cilint), so only enable it if no such ids are used side by side.
+	MigrateLegacyMarkers bool
 }
 
 type IDMustBeSpecifiedError struct{}
@@ -26,24 +53,122 @@ type IssueCommentNotFoundError struct {
 }
 
 func (e IssueCommentNotFoundError) Error() string {
-	return fmt.Sprintf("comment with the id `%s' not found", e.ID.GetID())
+	return fmt.Sprintf("comment with the id `%s' not found", string(e.ID))
 }
 
-// FindIssueComment finds a issue comment and returns it
+type IDCollisionError struct {
+	ID     ID
+	Marker string
+}
+
+func (e IDCollisionError) Error() string {
+	return fmt.Sprintf("the id `%s' collides with other ids using the legacy marker `%s'", string(e.ID), e.Marker)
+}
+
+type IssueBodyNotDeletableError struct {
+	ID ID
+}
+
+func (e IssueBodyNotDeletableError) Error() string {
+	return fmt.Sprintf("the id `%s' is part of the issue body which cannot be deleted", string(e.ID))
+}
+
+// FindIssueComment finds a issue comment and returns it,
+// if several comments carry the id (e.g. after --hide-previous) the newest one is returned.
+// If MigrateLegacyMarkers is set, comments that were created by earlier versions are found by their legacy marker,
+// if more than one comment carries that legacy marker an IDCollisionError is returned.
+// If SkipUnverified is set, comments without a valid signature are ignored,
+// if TrustedAuthors is set, comments of other authors are ignored.
 func (gc *GithubComment) FindIssueComment(issueID int, id ID) (*github.Issue, *github.IssueComment, error) {
 	if id == "" {
 		return nil, nil, IDMustBeSpecifiedError{}
 	}
+	authors, err := gc.trustedAuthors()
+	if err != nil {
+		return nil, nil, err
+	}
 	magicMarker := makeMagicMarker(id)
+	legacyMarker := makeLegacyMagicMarker(id)
+	if legacyMarker == magicMarker || !gc.MigrateLegacyMarkers {
+		legacyMarker = ""
+	}
+
+	var legacyIssue *github.Issue
+	var legacyComment *github.IssueComment
+	legacyMatches := 0
 
 	issue, _, err := gc.Client.Issues.Get(gc.Context, gc.Owner, gc.Repository, issueID)
+	if err != nil {
+		return nil, nil, apiError(err)
+	}
+	if isTrustedAuthor(issue.GetUser(), authors) && gc.isVerified(issueID, issue.GetBody()) {
+		if strings.Contains(issue.GetBody(), magicMarker) {
+			return issue, nil, nil
+		}
+		if legacyMarker != "" && strings.Contains(issue.GetBody(), legacyMarker) {
+			legacyIssue = issue
+			legacyMatches++
+		}
+	}
+
+	var found *github.IssueComment
+	err = gc.eachIssueComment(issueID, func(comment *github.IssueComment) bool {
+		if !isTrustedAuthor(comment.GetUser(), authors) || !gc.isVerified(issueID, comment.GetBody()) {
+			return true
+		}
+		if strings.Contains(comment.GetBody(), magicMarker) {
+			found = comment
+			return true
+		}
+		if legacyMarker != "" && strings.Contains(comment.GetBody(), legacyMarker) {
+			if legacyMatches == 0 {
+				legacyComment = comment
+			}
+			legacyMatches++
+		}
+		return true
+	})
 	if err != nil {
 		return nil, nil, err
 	}
-	if strings.Contains(issue.GetBody(), magicMarker) {
-		return issue, nil, nil
+	if found != nil {
+		return nil, found, nil
+	}
+
+	switch legacyMatches {
+	case 0:
+		return nil, nil, IssueCommentNotFoundError{ID: id}
+	case 1:
+		return legacyIssue, legacyComment, nil
+	default:
+		return nil, nil, IDCollisionError{ID: id, Marker: id.GetID()}
+	}
+}
+
+// FindIssueComments returns all comments with the id (in the order they were created),
+// the same restrictions as in FindIssueComment apply, markers of earlier versions are not considered.
+func (gc *GithubComment) FindIssueComments(issueID int, id ID) ([]*github.IssueComment, error) {
+	if id == "" {
+		return nil, IDMustBeSpecifiedError{}
+	}
+	authors, err := gc.trustedAuthors()
+	if err != nil {
+		return nil, err
 	}
+	magicMarker := makeMagicMarker(id)
+
+	var found []*github.IssueComment
+	err = gc.eachIssueComment(issueID, func(comment *github.IssueComment) bool {
+		if strings.Contains(comment.GetBody(), magicMarker) && isTrustedAuthor(comment.GetUser(), authors) && gc.isVerified(issueID, comment.GetBody()) {
+			found = append(found, comment)
+		}
+		return true
+	})
+	return found, err
+}
 
+// eachIssueComment calls fn for every comment of the issue until fn returns false
+func (gc *GithubComment) eachIssueComment(issueID int, fn func(comment *github.IssueComment) bool) error {
 	page := 1
 	for {
 		comments, res, err := gc.Client.Issues.ListComments(gc.Context, gc.Owner, gc.Repository, issueID, &github.IssueListCommentsOptions{
@@ -53,47 +178,70 @@ func (gc *GithubComment) FindIssueComment(issueID int, id ID) (*github.Issue, *g
 			},
 		})
 		if err != nil {
-			return nil, nil, err
+			return apiError(err)
 		}
 
 		for _, comment := range comments {
 			if comment.ID == nil {
 				continue
 			}
-			if strings.Contains(comment.GetBody(), magicMarker) {
-				return nil, comment, nil
+			if !fn(comment) {
+				return nil
 			}
 		}
 		if res.NextPage <= 0 {
-			return nil, nil, IssueCommentNotFoundError{ID: id}
+			return nil
 		}
 		page = res.NextPage
 	}
 }
 
-// PostIssueComment posts a new comment with the specified id
-func (gc *GithubComment) PostIssueComment(issueID int, id ID, text string, meta interface{}) error {
+// PostIssueComment posts a new comment with the specified id,
+// if the id is empty a new one will be generated
+func (gc *GithubComment) PostIssueComment(issueID int, id ID, text string, meta interface{}) (*Result, error) {
+	if id == "" {
+		id = ID(id.GetID())
+	}
 	info := Info{
 		ID:   id,
 		Body: text,
 		Meta: meta,
 	}
-	bodyText, err := info.Build()
+	bodyText, err := gc.buildInfo(issueID, &info)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if gc.DryRun {
+		return &Result{
+			ID:     id,
+			Action: ActionCreated,
+			Info:   &info,
+			DryRun: true,
+			Body:   bodyText,
+		}, nil
 	}
-	_, _, err = gc.Client.Issues.CreateComment(gc.Context, gc.Owner, gc.Repository, issueID, &github.IssueComment{
+	comment, _, err := gc.Client.Issues.CreateComment(gc.Context, gc.Owner, gc.Repository, issueID, &github.IssueComment{
 		Body: &bodyText,
 	})
-	return err
+	if err != nil {
+		return nil, apiError(err)
+	}
+	info.setComment(comment)
+	return &Result{
+		ID:        id,
+		CommentID: comment.GetID(),
+		HTMLURL:   comment.GetHTMLURL(),
+		Action:    ActionCreated,
+		Info:      &info,
+	}, nil
 }
 
 // UpdateIssueComment updates an existing comment
-func (gc *GithubComment) UpdateIssueComment(issueID int, id ID, text string, meta interface{}) error {
+func (gc *GithubComment) UpdateIssueComment(issueID int, id ID, text string, meta interface{}) (*Result, error) {
 	issue, comment, err := gc.FindIssueComment(issueID, id)
 	if err != nil {
 		if _, ok := err.(IssueCommentNotFoundError); !ok {
-			return err
+			return nil, err
 		}
 		return gc.PostIssueComment(issueID, id, text, meta)
 	}
@@ -102,25 +250,115 @@ func (gc *GithubComment) UpdateIssueComment(issueID int, id ID, text string, met
 		Body: text,
 		Meta: meta,
 	}
-	bodyText, err := info.Build()
+	if issue != nil {
+		gc.archive(issue.GetBody(), issue.UpdatedAt, &info)
+		return gc.editIssueComment(issueID, issue, comment, &info)
+	}
+	gc.archive(comment.GetBody(), comment.UpdatedAt, &info)
+	if gc.StickyBottom {
+		// an unchanged comment is not reposted, that would notify everyone on every run
+		bodyText, err := gc.buildInfo(issueID, &info)
+		if err != nil {
+			return nil, err
+		}
+		if !gc.isUnchanged(issueID, comment.GetBody(), bodyText, &info) {
+			newer, err := gc.hasNewerIssueComments(issueID, comment)
+			if err != nil {
+				return nil, err
+			}
+			if newer {
+				return gc.repostIssueComment(issueID, comment, &info)
+			}
+		}
+	}
+	return gc.editIssueComment(issueID, issue, comment, &info)
+}
+
+// SetIssueCommentMeta replaces the meta of an existing comment and keeps its body
+func (gc *GithubComment) SetIssueCommentMeta(issueID int, id ID, meta interface{}) (*Result, error) {
+	issue, comment, err := gc.FindIssueComment(issueID, id)
+	if err != nil {
+		return nil, err
+	}
+	raw := comment.GetBody()
+	if issue != nil {
+		raw = issue.GetBody()
+	}
+	// the current meta is not validated, so invalid meta can be replaced
+	info, err := ParseInfo(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := gc.checkDecryptable(info); err != nil {
+		return nil, err
+	}
+	return gc.editIssueComment(issueID, issue, comment, &Info{
+		ID:      id,
+		Body:    info.Body,
+		Meta:    meta,
+		History: info.History,
+	})
+}
+
+// editIssueComment writes the info to the issue body (if issue is not nil) or to the comment
+func (gc *GithubComment) editIssueComment(issueID int, issue *github.Issue, comment *github.IssueComment, info *Info) (*Result, error) {
+	bodyText, err := gc.buildInfo(issueID, info)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	result := Result{
+		ID:     info.ID,
+		Action: ActionUnchanged,
+		Info:   info,
+	}
+	if gc.DryRun {
+		result.DryRun = true
+		result.Body = bodyText
 	}
 	if issue != nil {
-		_, _, err = gc.Client.Issues.Edit(gc.Context, gc.Owner, gc.Repository, issueID, &github.IssueRequest{
+		info.setIssue(issue)
+		result.HTMLURL = issue.GetHTMLURL()
+		if gc.isUnchanged(issueID, issue.GetBody(), bodyText, info) {
+			return &result, nil
+		}
+		if gc.DryRun {
+			result.Action = ActionUpdated
+			result.PreviousBody = issue.GetBody()
+			return &result, nil
+		}
+		if issue, _, err = gc.Client.Issues.Edit(gc.Context, gc.Owner, gc.Repository, issueID, &github.IssueRequest{
 			Body: &bodyText,
-		})
-		return err
+		}); err != nil {
+			return nil, apiError(err)
+		}
+		info.setIssue(issue)
+		result.Action = ActionUpdated
+		return &result, nil
+	}
+	info.setComment(comment)
+	result.CommentID = comment.GetID()
+	result.HTMLURL = comment.GetHTMLURL()
+	if gc.isUnchanged(issueID, comment.GetBody(), bodyText, info) {
+		return &result, nil
+	}
+	if gc.DryRun {
+		result.Action = ActionUpdated
+		result.PreviousBody = comment.GetBody()
+		return &result, nil
 	}
-	_, _, err = gc.Client.Issues.EditComment(gc.Context, gc.Owner, gc.Repository, comment.GetID(), &github.IssueComment{
+	if comment, _, err = gc.Client.Issues.EditComment(gc.Context, gc.Owner, gc.Repository, comment.GetID(), &github.IssueComment{
 		Body: &bodyText,
-	})
-	return err
+	}); err != nil {
+		return nil, apiError(err)
+	}
+	info.setComment(comment)
+	result.Action = ActionUpdated
+	return &result, nil
 }
 
 // PostOrUpdateIssueComment  posts an new comment if it was not able to update the existing comment,
 // if you omit the ID it will always post a new comment
-func (gc *GithubComment) PostOrUpdateIssueComment(issueID int, id ID, text string, meta interface{}) error {
+func (gc *GithubComment) PostOrUpdateIssueComment(issueID int, id ID, text string, meta interface{}) (*Result, error) {
 	// if id is not specified
 	if id == "" {
 		return gc.PostIssueComment(issueID, id, text, meta)
@@ -135,8 +373,236 @@ func (gc *GithubComment) GetIssueComment(issueID int, id ID) (*Info, error) {
 		return nil, err
 	}
 	if issue != nil {
-		return ParseInfo(issue.GetBody())
+		info, err := gc.parseInfo(issueID, issue.GetBody())
+		if err != nil {
+			return nil, err
+		}
+		info.setIssue(issue)
+		return info, nil
+	}
+
+	info, err := gc.parseInfo(issueID, comment.GetBody())
+	if err != nil {
+		return nil, err
+	}
+	info.setComment(comment)
+	return info, nil
+}
+
+// ListIssueComments returns the infos of the issue body and all comments that carry a marker,
+// the same restrictions as in FindIssueComment apply
+func (gc *GithubComment) ListIssueComments(issueID int) ([]*Info, error) {
+	authors, err := gc.trustedAuthors()
+	if err != nil {
+		return nil, err
+	}
+	issue, _, err := gc.Client.Issues.Get(gc.Context, gc.Owner, gc.Repository, issueID)
+	if err != nil {
+		return nil, apiError(err)
+	}
+	var infos []*Info
+	if isTrustedAuthor(issue.GetUser(), authors) && gc.isVerified(issueID, issue.GetBody()) {
+		if info, err := gc.parseInfo(issueID, issue.GetBody()); err == nil {
+			info.setIssue(issue)
+			infos = append(infos, info)
+		}
+	}
+	err = gc.eachIssueComment(issueID, func(comment *github.IssueComment) bool {
+		if !isTrustedAuthor(comment.GetUser(), authors) || !gc.isVerified(issueID, comment.GetBody()) {
+			return true
+		}
+		if info, err := gc.parseInfo(issueID, comment.GetBody()); err == nil {
+			info.setComment(comment)
+			infos = append(infos, info)
+		}
+		return true
+	})
+	return infos, err
+}
+
+// DeleteIssueComment deletes the comment with the id
+func (gc *GithubComment) DeleteIssueComment(issueID int, id ID) (*Result, error) {
+	issue, comment, err := gc.FindIssueComment(issueID, id)
+	if err != nil {
+		return nil, err
+	}
+	if issue != nil {
+		return nil, IssueBodyNotDeletableError{ID: id}
+	}
+	return gc.deleteIssueComment(issueID, id, comment)
+}
+
+// deleteIssueComment deletes the comment and returns the info it held
+func (gc *GithubComment) deleteIssueComment(issueID int, id ID, comment *github.IssueComment) (*Result, error) {
+	info, err := gc.parseInfo(issueID, comment.GetBody())
+	if err != nil {
+		// the comment is deleted anyway, so its body does not need to be valid
+		info = &Info{ID: id, Body: comment.GetBody()}
+	}
+	info.setComment(comment)
+	result := &Result{
+		ID:        info.ID,
+		CommentID: comment.GetID(),
+		HTMLURL:   comment.GetHTMLURL(),
+		Action:    ActionDeleted,
+		Info:      info,
+	}
+	if gc.DryRun {
+		result.DryRun = true
+		result.PreviousBody = comment.GetBody()
+		return result, nil
+	}
+	if _, err := gc.Client.Issues.DeleteComment(gc.Context, gc.Owner, gc.Repository, comment.GetID()); err != nil {
+		return nil, apiError(err)
+	}
+	return result, nil
+}
+
+// isUnchanged reports whether the raw body already holds the info,
+// encrypted meta is compared after decrypting it because every encryption yields a different body
+func (gc *GithubComment) isUnchanged(issueID int, raw, bodyText string, info *Info) bool {
+	if raw == bodyText {
+		return true
+	}
+	if len(gc.MetaKeys) == 0 || info.Meta == nil {
+		return false
+	}
+	// meta that is not encrypted with the current key is written again
+	written, err := ParseInfo(raw)
+	if err != nil {
+		return false
+	}
+	if kid, ok := encryptedMetaKeyID(written.Meta); !ok || kid != gc.MetaKeys[0].ID {
+		return false
+	}
+	existing, err := gc.parseInfo(issueID, raw)
+	if err != nil || existing.ID.Canonical() != info.ID.Canonical() || existing.Body != info.Body || !reflect.DeepEqual(existing.History, info.History) {
+		return false
+	}
+	return sameJSON(existing.Meta, info.Meta)
+}
+
+// isVerified reports whether the raw body of a comment should be considered by lookups
+func (gc *GithubComment) isVerified(issueID int, raw string) bool {
+	if !gc.SkipUnverified || len(gc.SigningKey) == 0 {
+		return true
+	}
+	return verifySignature(gc.SigningKey, gc.signatureScope(issueID), raw)
+}
+
+// buildInfo validates, encrypts, signs and builds the info
+func (gc *GithubComment) buildInfo(issueID int, info *Info) (string, error) {
+	if gc.MetaSchema != nil {
+		if err := gc.MetaSchema.Validate(info.Meta); err != nil {
+			return "", err
+		}
+	}
+	written := *info
+	written.Signature = ""
+	if len(gc.MetaKeys) > 0 && info.Meta != nil {
+		meta, err := encryptMeta(gc.MetaKeys[0], info.ID, info.Meta)
+		if err != nil {
+			return "", err
+		}
+		written.Meta = meta
+	}
+	raw, err := written.Build()
+	if err != nil {
+		return "", err
+	}
+	// drop the oldest history entries until the body (and the signature) fits
+	reserve := 0
+	if len(gc.SigningKey) > 0 {
+		reserve = len(makeSignatureMarker(sign(gc.SigningKey, "", "")))
+	}
+	for !fitsBody(raw, reserve) && len(written.History) > 0 {
+		written.History = written.History[:len(written.History)-1]
+		if raw, err = written.Build(); err != nil {
+			return "", err
+		}
+	}
+	info.History = written.History
+	if len(gc.SigningKey) == 0 {
+		return raw, nil
+	}
+	info.Signature = sign(gc.SigningKey, gc.signatureScope(issueID), raw)
+	info.Verified = true
+	written.Signature = info.Signature
+	return written.Build()
+}
+
+// parseInfo parses, decrypts and validates the body of a comment of the issue,
+// meta that cannot be decrypted or does not match the MetaSchema is reported in the MetaError of the info,
+// so the body can still be read
+func (gc *GithubComment) parseInfo(issueID int, raw string) (*Info, error) {
+	info, err := ParseInfo(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(gc.SigningKey) > 0 {
+		info.Verified = verifySignature(gc.SigningKey, gc.signatureScope(issueID), raw)
+	}
+	if isEncryptedMeta(info.Meta) {
+		if info.Meta, err = decryptMeta(gc.MetaKeys, info.ID, info.Meta); err != nil {
+			info.Meta = nil
+			info.MetaError = err.Error()
+			info.metaErr = err
+			return info, nil
+		}
+	}
+	if gc.MetaSchema != nil {
+		if err := gc.MetaSchema.Validate(info.Meta); err != nil {
+			info.MetaError = err.Error()
+		}
+	}
+	return info, nil
+}
+
+// checkDecryptable returns the decryption error of encrypted meta, such meta is not replaced
+// because it would be lost for the readers that have the key
+func (gc *GithubComment) checkDecryptable(info *Info) error {
+	if !isEncryptedMeta(info.Meta) {
+		return nil
 	}
+	_, err := decryptMeta(gc.MetaKeys, info.ID, info.Meta)
+	return err
+}
+
+// setIssue fills the metadata of the info with the issue
+func (i *Info) setIssue(issue *github.Issue) {
+	i.CommentID = 0
+	i.Location = LocationIssue
+	i.Author = issue.GetUser().GetLogin()
+	i.AuthorType = issue.GetUser().GetType()
+	i.CreatedAt = issue.CreatedAt
+	i.UpdatedAt = issue.UpdatedAt
+	i.HTMLURL = issue.GetHTMLURL()
+	i.Reactions = makeReactions(issue.Reactions)
+}
+
+// setComment fills the metadata of the info with the comment
+func (i *Info) setComment(comment *github.IssueComment) {
+	i.CommentID = comment.GetID()
+	i.Location = LocationComment
+	i.Author = comment.GetUser().GetLogin()
+	i.AuthorType = comment.GetUser().GetType()
+	i.CreatedAt = comment.CreatedAt
+	i.UpdatedAt = comment.UpdatedAt
+	i.HTMLURL = comment.GetHTMLURL()
+	i.Reactions = makeReactions(comment.Reactions)
+}
 
-	return ParseInfo(comment.GetBody())
+func makeReactions(r *github.Reactions) *Reactions {
+	if r == nil {
+		return nil
+	}
+	return &Reactions{
+		Total:    r.GetTotalCount(),
+		PlusOne:  r.GetPlusOne(),
+		MinusOne: r.GetMinusOne(),
+		Laugh:    r.GetLaugh(),
+		Confused: r.GetConfused(),
+		Heart:    r.GetHeart(),
+		Hooray:   r.GetHooray(),
+	}
 }
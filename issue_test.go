@@ -0,0 +1,365 @@
+package githubcomment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGithub is a minimal in memory implementation of the issue comments api
+type fakeGithub struct {
+	mu       sync.Mutex
+	issue    *github.Issue
+	comments []*github.IssueComment
+	nextID   int64
+	user     string
+	// minimized holds the classifier of minimized comments by their node id
+	minimized map[string]string
+	// pulls are the open pull requests
+	pulls []*github.PullRequest
+	// userRequests counts the requests for the authenticated user
+	userRequests int
+}
+
+func newFakeGithub(t *testing.T, body string) (*fakeGithub, *GithubComment) {
+	f := &fakeGithub{
+		issue: &github.Issue{
+			Number:  github.Int(1),
+			Body:    github.String(body),
+			HTMLURL: github.String("https://github.com/owner/repo/issues/1"),
+			User:    &github.User{Login: github.String("author"), Type: github.String("User")},
+		},
+		nextID:    100,
+		user:      "bot",
+		minimized: map[string]string{},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return f, &GithubComment{
+		Client:     client,
+		Context:    context.Background(),
+		Owner:      "owner",
+		Repository: "repo",
+	}
+}
+
+// addComment adds a comment as the specified user
+func (f *fakeGithub) addComment(user, body string) *github.IssueComment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	now := time.Now()
+	comment := &github.IssueComment{
+		ID:        github.Int64(f.nextID),
+		NodeID:    github.String(fmt.Sprintf("IC_%d", f.nextID)),
+		Body:      github.String(body),
+		User:      &github.User{Login: github.String(user), Type: github.String("User")},
+		CreatedAt: &now,
+		UpdatedAt: &now,
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/owner/repo/issues/1#issuecomment-%d", f.nextID)),
+		IssueURL:  github.String("https://api.github.com/repos/owner/repo/issues/1"),
+	}
+	f.comments = append(f.comments, comment)
+	return comment
+}
+
+func (f *fakeGithub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const issuePath = "/repos/owner/repo/issues/1"
+	const commentPath = "/repos/owner/repo/issues/comments/"
+
+	switch {
+	case r.URL.Path == "/graphql" && r.Method == http.MethodPost:
+		f.serveGraphQL(w, r)
+	case r.URL.Path == "/repos/owner/repo/pulls" && r.Method == http.MethodGet:
+		var pulls []*github.PullRequest
+		for _, pull := range f.pulls {
+			if pull.GetHead().GetLabel() == r.URL.Query().Get("head") {
+				pulls = append(pulls, pull)
+			}
+		}
+		json.NewEncoder(w).Encode(pulls)
+	case strings.HasPrefix(r.URL.Path, "/repos/owner/repo/commits/") && strings.HasSuffix(r.URL.Path, "/pulls"):
+		sha := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/commits/"), "/pulls")
+		var pulls []*github.PullRequest
+		for _, pull := range f.pulls {
+			if pull.GetHead().GetSHA() == sha {
+				pulls = append(pulls, pull)
+			}
+		}
+		json.NewEncoder(w).Encode(pulls)
+	case r.URL.Path == "/markdown" && r.Method == http.MethodPost:
+		var req struct {
+			Text    string `json:"text"`
+			Mode    string `json:"mode"`
+			Context string `json:"context"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, "<p data-mode=\"%s\" data-context=\"%s\">%s</p>\n", req.Mode, req.Context, req.Text)
+	case r.URL.Path == "/user" && r.Method == http.MethodGet:
+		f.mu.Lock()
+		f.userRequests++
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(&github.User{Login: github.String(f.user), Type: github.String("User")})
+	case r.URL.Path == issuePath && r.Method == http.MethodGet:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.issue)
+	case r.URL.Path == issuePath && r.Method == http.MethodPatch:
+		var req github.IssueRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.issue.Body = req.Body
+		json.NewEncoder(w).Encode(f.issue)
+	case r.URL.Path == issuePath+"/comments" && r.Method == http.MethodGet:
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(f.comments)
+	case r.URL.Path == issuePath+"/comments" && r.Method == http.MethodPost:
+		var req github.IssueComment
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(f.addComment(f.user, req.GetBody()))
+	case strings.HasPrefix(r.URL.Path, commentPath):
+		id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, commentPath), 10, 64)
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, comment := range f.comments {
+			if comment.GetID() != id {
+				continue
+			}
+			switch r.Method {
+			case http.MethodPatch:
+				var req github.IssueComment
+				json.NewDecoder(r.Body).Decode(&req)
+				comment.Body = req.Body
+				json.NewEncoder(w).Encode(comment)
+			case http.MethodDelete:
+				f.comments = append(f.comments[:i], f.comments[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				json.NewEncoder(w).Encode(comment)
+			}
+			return
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveGraphQL is a stand-in for the graphql api that only knows the minimizeComment mutation
+// and the nodes query for the isMinimized of comments
+func (f *fakeGithub) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query     string `json:"query"`
+		Variables struct {
+			ID         string   `json:"id"`
+			IDs        []string `json:"ids"`
+			Classifier string   `json:"classifier"`
+		} `json:"variables"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if strings.Contains(req.Query, "nodes(") {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		nodes := []interface{}{}
+		for _, id := range req.Variables.IDs {
+			_, minimized := f.minimized[id]
+			nodes = append(nodes, map[string]interface{}{"id": id, "isMinimized": minimized})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"nodes": nodes}})
+		return
+	}
+	if !strings.Contains(req.Query, "minimizeComment") {
+		json.NewEncoder(w).Encode(map[string]interface{}{"errors": []interface{}{map[string]string{"message": "unknown query"}}})
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, comment := range f.comments {
+		if comment.GetNodeID() == req.Variables.ID {
+			f.minimized[req.Variables.ID] = req.Variables.Classifier
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"minimizeComment": map[string]interface{}{
+						"minimizedComment": map[string]interface{}{"isMinimized": true},
+					},
+				},
+			})
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": []interface{}{map[string]string{"message": "Could not resolve to a node with the global id of '" + req.Variables.ID + "'"}}})
+}
+
+func TestFindIssueCommentLegacyMarker(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.MigrateLegacyMarkers = true
+	legacy := f.addComment("bot", makeLegacyMagicMarker(ID("ci/lint"))+"\nold")
+
+	_, comment, err := gc.FindIssueComment(1, ID("ci/lint"))
+	require.NoError(t, err)
+	require.Equal(t, legacy.GetID(), comment.GetID())
+
+	// updating migrates the comment to the new marker
+	result, err := gc.UpdateIssueComment(1, ID("ci/lint"), "new", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+	require.Equal(t, legacy.GetID(), result.CommentID)
+	require.Contains(t, legacy.GetBody(), makeMagicMarker(ID("ci/lint")))
+
+	// more than one legacy comment makes the lookup ambiguous
+	f.addComment("bot", makeLegacyMagicMarker(ID("ci lint"))+"\nold")
+	f.addComment("bot", makeLegacyMagicMarker(ID("ci_lint"))+"\nold")
+	_, _, err = gc.FindIssueComment(1, ID("ci lint"))
+	require.Equal(t, IDCollisionError{ID: ID("ci lint"), Marker: "cilint"}, err)
+}
+
+func TestLegacyMarkerDoesNotTakeOverOtherID(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	// the legacy marker of ci/lint is the marker of cilint
+	other, err := gc.PostIssueComment(1, ID("cilint"), "cilint", nil)
+	require.NoError(t, err)
+
+	_, _, err = gc.FindIssueComment(1, ID("ci/lint"))
+	require.Equal(t, IssueCommentNotFoundError{ID: ID("ci/lint")}, err)
+
+	result, err := gc.UpdateIssueComment(1, ID("ci/lint"), "ci/lint", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionCreated, result.Action)
+	require.NotEqual(t, other.CommentID, result.CommentID)
+	require.Len(t, f.comments, 2)
+
+	info, err := gc.GetIssueComment(1, ID("cilint"))
+	require.NoError(t, err)
+	require.Equal(t, "cilint", info.Body)
+}
+
+func TestUpdateIssueComment(t *testing.T) {
+	_, gc := newFakeGithub(t, "")
+
+	result, err := gc.UpdateIssueComment(1, ID("build"), "Hello World", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionCreated, result.Action)
+	require.NotZero(t, result.CommentID)
+
+	result, err = gc.UpdateIssueComment(1, ID("build"), "Hello World", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUnchanged, result.Action)
+
+	result, err = gc.UpdateIssueComment(1, ID("build"), "Hello Universe", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+
+	info, err := gc.GetIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.Equal(t, "Hello Universe", info.Body)
+	require.Equal(t, result.CommentID, info.CommentID)
+	require.Equal(t, LocationComment, info.Location)
+	require.Equal(t, "bot", info.Author)
+	require.Equal(t, result.HTMLURL, info.HTMLURL)
+}
+
+func TestPostIssueCommentGeneratesID(t *testing.T) {
+	_, gc := newFakeGithub(t, "")
+
+	result, err := gc.PostIssueComment(1, "", "Hello World", nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, result.ID)
+
+	info, err := gc.GetIssueComment(1, result.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.ID, info.ID)
+}
+
+func TestGetIssueCommentInIssueBody(t *testing.T) {
+	_, gc := newFakeGithub(t, makeMagicMarker(ID("deploy"))+"\nHello World")
+
+	info, err := gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.Equal(t, LocationIssue, info.Location)
+	require.Equal(t, "author", info.Author)
+	require.Zero(t, info.CommentID)
+}
+
+func TestDeleteIssueComment(t *testing.T) {
+	f, gc := newFakeGithub(t, makeMagicMarker(ID("deploy"))+"\nHello World")
+	build := f.addComment("bot", makeMagicMarker(ID("build"))+"\nHello World")
+
+	result, err := gc.DeleteIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.Equal(t, ActionDeleted, result.Action)
+	require.Equal(t, build.GetID(), result.CommentID)
+	require.Equal(t, "Hello World", result.Info.Body)
+	require.Empty(t, f.comments)
+
+	_, err = gc.DeleteIssueComment(1, ID("build"))
+	require.Equal(t, IssueCommentNotFoundError{ID: ID("build")}, err)
+
+	_, err = gc.DeleteIssueComment(1, ID("deploy"))
+	require.Equal(t, IssueBodyNotDeletableError{ID: ID("deploy")}, err)
+}
+
+func TestListIssueComments(t *testing.T) {
+	f, gc := newFakeGithub(t, makeMagicMarker(ID("deploy"))+"\nHello World")
+	f.addComment("bot", "no marker")
+	f.addComment("bot", makeMagicMarker(ID("build"))+"\nHello World")
+
+	infos, err := gc.ListIssueComments(1)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	require.Equal(t, ID("deploy"), infos[0].ID)
+	require.Equal(t, LocationIssue, infos[0].Location)
+	require.Equal(t, ID("build"), infos[1].ID)
+	require.Equal(t, LocationComment, infos[1].Location)
+}
+
+func TestDryRun(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.DryRun = true
+
+	result, err := gc.UpdateIssueComment(1, ID("build"), "Hello World", nil)
+	require.NoError(t, err)
+	require.True(t, result.DryRun)
+	require.Equal(t, ActionCreated, result.Action)
+	require.Contains(t, result.Body, makeMagicMarker(ID("build")))
+	require.Empty(t, f.comments)
+
+	existing := f.addComment("bot", makeMagicMarker(ID("build"))+"\nHello World")
+	result, err = gc.UpdateIssueComment(1, ID("build"), "Hello World", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUnchanged, result.Action)
+
+	result, err = gc.UpdateIssueComment(1, ID("build"), "Hello Universe", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+	require.Equal(t, existing.GetID(), result.CommentID)
+	require.Equal(t, existing.GetBody(), result.PreviousBody)
+	require.Equal(t, makeMagicMarker(ID("build"))+"\nHello World", existing.GetBody())
+
+	result, err = gc.DeleteIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.Equal(t, ActionDeleted, result.Action)
+	require.Len(t, f.comments, 1)
+
+	result, err = gc.PostAndHideIssueComment(1, ID("build"), "Hello Universe", nil)
+	require.NoError(t, err)
+	require.Equal(t, []int64{existing.GetID()}, result.Hidden)
+	require.Len(t, f.comments, 1)
+	require.Empty(t, f.minimized)
+}
@@ -0,0 +1,18 @@
+package githubcomment
+
+import (
+	"github.com/google/go-github/github"
+)
+
+// RenderMarkdown renders text to html the way github renders comments,
+// mentions and references are linked relative to the repository
+func (gc *GithubComment) RenderMarkdown(text string) (string, error) {
+	html, _, err := gc.Client.Markdown(gc.Context, text, &github.MarkdownOptions{
+		Mode:    "gfm",
+		Context: gc.Owner + "/" + gc.Repository,
+	})
+	if err != nil {
+		return "", apiError(err)
+	}
+	return html, nil
+}
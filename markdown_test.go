@@ -0,0 +1,14 @@
+package githubcomment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	_, gc := newFakeGithub(t, "")
+	html, err := gc.RenderMarkdown("Hello World")
+	require.NoError(t, err)
+	require.Equal(t, "<p data-mode=\"gfm\" data-context=\"owner/repo\">Hello World</p>\n", html)
+}
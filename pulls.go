@@ -0,0 +1,64 @@
+package githubcomment
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/github"
+)
+
+// FindPullRequestsByBranch returns the numbers of the open pull requests with the branch as head,
+// the branch can be prefixed with the owner (owner:branch) for pull requests from forks
+func (gc *GithubComment) FindPullRequestsByBranch(branch string) ([]int, error) {
+	head := branch
+	if !strings.Contains(head, ":") {
+		head = gc.Owner + ":" + branch
+	}
+	var numbers []int
+	opt := &github.PullRequestListOptions{
+		State: "open",
+		Head:  head,
+		ListOptions: github.ListOptions{
+			PerPage: 30,
+		},
+	}
+	for {
+		pulls, res, err := gc.Client.PullRequests.List(gc.Context, gc.Owner, gc.Repository, opt)
+		if err != nil {
+			return nil, apiError(err)
+		}
+		for _, pull := range pulls {
+			numbers = append(numbers, pull.GetNumber())
+		}
+		if res.NextPage <= 0 {
+			return numbers, nil
+		}
+		opt.Page = res.NextPage
+	}
+}
+
+// FindPullRequestsByCommit returns the numbers of the pull requests that are associated with the commit
+func (gc *GithubComment) FindPullRequestsByCommit(sha string) ([]int, error) {
+	var numbers []int
+	page := 1
+	for {
+		req, err := gc.Client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/commits/%s/pulls?per_page=30&page=%d", gc.Owner, gc.Repository, sha, page), nil)
+		if err != nil {
+			return nil, err
+		}
+		// the endpoint is still in preview
+		req.Header.Set("Accept", "application/vnd.github.groot-preview+json")
+		var pulls []*github.PullRequest
+		res, err := gc.Client.Do(gc.Context, req, &pulls)
+		if err != nil {
+			return nil, apiError(err)
+		}
+		for _, pull := range pulls {
+			numbers = append(numbers, pull.GetNumber())
+		}
+		if res.NextPage <= 0 {
+			return numbers, nil
+		}
+		page = res.NextPage
+	}
+}
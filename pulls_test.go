@@ -0,0 +1,43 @@
+package githubcomment
+
+import (
+	"testing"
+
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/require"
+)
+
+func newPullRequest(number int, label, sha string) *github.PullRequest {
+	return &github.PullRequest{
+		Number: github.Int(number),
+		Head: &github.PullRequestBranch{
+			Label: github.String(label),
+			SHA:   github.String(sha),
+		},
+	}
+}
+
+func TestFindPullRequests(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	f.pulls = []*github.PullRequest{
+		newPullRequest(2, "owner:feature", "aaa"),
+		newPullRequest(3, "fork:feature", "bbb"),
+		newPullRequest(4, "owner:other", "bbb"),
+	}
+
+	numbers, err := gc.FindPullRequestsByBranch("feature")
+	require.NoError(t, err)
+	require.Equal(t, []int{2}, numbers)
+
+	numbers, err = gc.FindPullRequestsByBranch("fork:feature")
+	require.NoError(t, err)
+	require.Equal(t, []int{3}, numbers)
+
+	numbers, err = gc.FindPullRequestsByBranch("unknown")
+	require.NoError(t, err)
+	require.Empty(t, numbers)
+
+	numbers, err = gc.FindPullRequestsByCommit("bbb")
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 4}, numbers)
+}
@@ -0,0 +1,37 @@
+package githubcomment
+
+// Action describes what happened to a comment
+type Action string
+
+const (
+	// ActionCreated is used when a new comment was posted
+	ActionCreated Action = "created"
+	// ActionUpdated is used when an existing comment (or issue body) was edited
+	ActionUpdated Action = "updated"
+	// ActionUnchanged is used when the existing comment already had the desired content
+	ActionUnchanged Action = "unchanged"
+	// ActionReposted is used when the existing comment was deleted and posted again as the newest comment
+	ActionReposted Action = "reposted"
+	// ActionDeleted is used when the comment was deleted
+	ActionDeleted Action = "deleted"
+)
+
+// Result is returned by the functions that write comments
+type Result struct {
+	// ID is the effective id, if no id was specified this is the generated one
+	ID ID `json:"id"`
+	// CommentID is the id of the github comment, it is 0 if the marker lives in the issue body
+	CommentID int64 `json:"comment_id,omitempty"`
+	// HTMLURL links to the comment (or issue)
+	HTMLURL string `json:"html_url"`
+	Action  Action `json:"action"`
+	Info    *Info  `json:"info"`
+	// Hidden holds the ids of the comments that were minimized
+	Hidden []int64 `json:"hidden,omitempty"`
+	// DryRun is set if nothing was written, Action tells what would have happened
+	DryRun bool `json:"dry_run,omitempty"`
+	// Body is the raw body that would have been written (only for dry runs)
+	Body string `json:"body,omitempty"`
+	// PreviousBody is the current raw body of the comment (only for dry runs)
+	PreviousBody string `json:"previous_body,omitempty"`
+}
@@ -0,0 +1,49 @@
+package githubcomment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+
+	created, err := gc.UpdateIssueComment(1, ID("build"), "Hello World", map[string]interface{}{"ok": true})
+	require.NoError(t, err)
+	require.Equal(t, ActionCreated, created.Action)
+	require.Equal(t, ID("build"), created.ID)
+	require.Equal(t, f.comments[0].GetID(), created.CommentID)
+	require.Equal(t, f.comments[0].GetHTMLURL(), created.HTMLURL)
+	require.Equal(t, "Hello World", created.Info.Body)
+	require.Equal(t, map[string]interface{}{"ok": true}, created.Info.Meta)
+	require.False(t, created.DryRun)
+
+	unchanged, err := gc.UpdateIssueComment(1, ID("build"), "Hello World", map[string]interface{}{"ok": true})
+	require.NoError(t, err)
+	require.Equal(t, ActionUnchanged, unchanged.Action)
+	require.Equal(t, created.CommentID, unchanged.CommentID)
+	require.Equal(t, created.HTMLURL, unchanged.HTMLURL)
+
+	updated, err := gc.UpdateIssueComment(1, ID("build"), "Hello Universe", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, updated.Action)
+	require.Equal(t, created.CommentID, updated.CommentID)
+	require.Equal(t, "Hello Universe", updated.Info.Body)
+
+	deleted, err := gc.DeleteIssueComment(1, ID("build"))
+	require.NoError(t, err)
+	require.Equal(t, ActionDeleted, deleted.Action)
+	require.Equal(t, created.CommentID, deleted.CommentID)
+}
+
+func TestResultInIssueBody(t *testing.T) {
+	_, gc := newFakeGithub(t, makeMagicMarker(ID("deploy"))+"\nHello World")
+
+	result, err := gc.UpdateIssueComment(1, ID("deploy"), "Hello Universe", nil)
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, result.Action)
+	require.Zero(t, result.CommentID)
+	require.Equal(t, "https://github.com/owner/repo/issues/1", result.HTMLURL)
+	require.Equal(t, LocationIssue, result.Info.Location)
+}
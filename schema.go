@@ -0,0 +1,489 @@
+package githubcomment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema that is used to validate the meta of a comment.
+// The keywords type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, uniqueItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, allOf, anyOf, oneOf, not and local $ref are supported,
+// all other keywords are ignored. The array form of items and the boolean form of
+// exclusiveMinimum and exclusiveMaximum are rejected.
+type Schema struct {
+	root interface{}
+	// patterns holds the compiled patterns of all subschemas
+	patterns map[string]*regexp.Regexp
+}
+
+// MetaValidationError is returned when the meta does not match the schema
+type MetaValidationError struct {
+	// Path points to the offending field, e.g. meta.coverage or meta.jobs[2].name
+	Path    string
+	Message string
+}
+
+func (e MetaValidationError) Error() string {
+	return fmt.Sprintf("invalid meta at `%s': %s", e.Path, e.Message)
+}
+
+// ParseSchema parses a JSON Schema,
+// references that cannot be resolved or that refer to themselves, invalid patterns and
+// unsupported forms of keywords (like the array form of items) are reported as errors
+func ParseSchema(data []byte) (*Schema, error) {
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("unable to parse schema: %v", err)
+	}
+	switch root.(type) {
+	case bool, map[string]interface{}:
+	default:
+		return nil, errors.New("unable to parse schema: schema must be an object or a boolean")
+	}
+	s := &Schema{root: root, patterns: map[string]*regexp.Regexp{}}
+	if err := s.check(root, map[uintptr]bool{}, map[uintptr]bool{}); err != nil {
+		return nil, fmt.Errorf("unable to parse schema: %v", err)
+	}
+	return s, nil
+}
+
+// check compiles the patterns and resolves the references of the schema and all of its subschemas
+func (s *Schema) check(schema interface{}, seen, done map[uintptr]bool) error {
+	sc, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	p := reflect.ValueOf(sc).Pointer()
+	if seen[p] {
+		return nil
+	}
+	seen[p] = true
+
+	if err := checkKeywordForms(sc); err != nil {
+		return err
+	}
+	if pattern, ok := sc["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern `%s': %v", pattern, err)
+		}
+		s.patterns[pattern] = re
+	}
+	if err := s.checkCycle(sc, map[uintptr]bool{}, done); err != nil {
+		return err
+	}
+
+	var children []interface{}
+	if ref, ok := sc["$ref"].(string); ok {
+		resolved, err := s.resolve(ref)
+		if err != nil {
+			return err
+		}
+		children = append(children, resolved)
+	}
+	for _, key := range []string{"properties", "definitions", "$defs"} {
+		if m, ok := sc[key].(map[string]interface{}); ok {
+			for _, child := range m {
+				children = append(children, child)
+			}
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if list, ok := sc[key].([]interface{}); ok {
+			children = append(children, list...)
+		}
+	}
+	children = append(children, sc["additionalProperties"], sc["items"], sc["not"])
+	for _, child := range children {
+		if err := s.check(child, seen, done); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKeywordForms reports keywords in forms that are not supported,
+// they would otherwise be ignored (or make every validation fail)
+func checkKeywordForms(sc map[string]interface{}) error {
+	if items, ok := sc["items"]; ok {
+		switch items.(type) {
+		case bool, map[string]interface{}:
+		case []interface{}:
+			return errors.New("the array form of `items' is not supported, use a schema")
+		default:
+			return errors.New("`items' must be a schema")
+		}
+	}
+	for _, key := range []string{"exclusiveMinimum", "exclusiveMaximum"} {
+		switch sc[key].(type) {
+		case nil, float64:
+		case bool:
+			return fmt.Errorf("the boolean form of `%s' is not supported, use a number", key)
+		default:
+			return fmt.Errorf("`%s' must be a number", key)
+		}
+	}
+	for _, key := range []string{"minimum", "maximum", "minItems", "maxItems", "minLength", "maxLength"} {
+		if v, ok := sc[key]; ok {
+			if _, ok := v.(float64); !ok {
+				return fmt.Errorf("`%s' must be a number", key)
+			}
+		}
+	}
+	return nil
+}
+
+// checkCycle reports references that lead back to the schema without validating a nested value,
+// which would recurse forever, done holds the schemas that are known to be free of cycles
+func (s *Schema) checkCycle(schema interface{}, stack, done map[uintptr]bool) error {
+	sc, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	p := reflect.ValueOf(sc).Pointer()
+	if done[p] {
+		return nil
+	}
+	if stack[p] {
+		return errors.New("cyclic reference")
+	}
+	stack[p] = true
+
+	var next []interface{}
+	if ref, ok := sc["$ref"].(string); ok {
+		resolved, err := s.resolve(ref)
+		if err != nil {
+			return err
+		}
+		next = append(next, resolved)
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		if list, ok := sc[key].([]interface{}); ok {
+			next = append(next, list...)
+		}
+	}
+	next = append(next, sc["not"])
+	for _, n := range next {
+		if err := s.checkCycle(n, stack, done); err != nil {
+			return err
+		}
+	}
+	delete(stack, p)
+	done[p] = true
+	return nil
+}
+
+// Validate validates the value against the schema
+func (s *Schema) Validate(v interface{}) error {
+	// bring the value in the same shape as it would be after parsing the comment
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(buf, &normalized); err != nil {
+		return err
+	}
+	return s.validate(s.root, normalized, "meta")
+}
+
+func (s *Schema) validate(schema interface{}, v interface{}, path string) error {
+	var sc map[string]interface{}
+	switch t := schema.(type) {
+	case bool:
+		if !t {
+			return MetaValidationError{Path: path, Message: "no value is allowed"}
+		}
+		return nil
+	case map[string]interface{}:
+		sc = t
+	default:
+		return fmt.Errorf("invalid schema for `%s'", path)
+	}
+
+	if ref, ok := sc["$ref"].(string); ok {
+		resolved, err := s.resolve(ref)
+		if err != nil {
+			return err
+		}
+		return s.validate(resolved, v, path)
+	}
+
+	if t, ok := sc["type"]; ok && !matchesType(t, v) {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("expected %s but got %s", describeType(t), jsonType(v))}
+	}
+
+	if enum, ok := sc["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			buf, _ := json.Marshal(enum)
+			return MetaValidationError{Path: path, Message: fmt.Sprintf("value must be one of %s", buf)}
+		}
+	}
+
+	if c, ok := sc["const"]; ok && !reflect.DeepEqual(c, v) {
+		buf, _ := json.Marshal(c)
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("value must be %s", buf)}
+	}
+
+	var err error
+	switch t := v.(type) {
+	case map[string]interface{}:
+		err = s.validateObject(sc, t, path)
+	case []interface{}:
+		err = s.validateArray(sc, t, path)
+	case string:
+		err = s.validateString(sc, t, path)
+	case float64:
+		err = validateNumber(sc, t, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.validateCombinations(sc, v, path)
+}
+
+func (s *Schema) validateObject(sc map[string]interface{}, v map[string]interface{}, path string) error {
+	if required, ok := sc["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := v[name]; !ok {
+				return MetaValidationError{Path: childPath(path, name), Message: "field is required"}
+			}
+		}
+	}
+
+	properties, _ := sc["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if property, ok := properties[key]; ok {
+			if err := s.validate(property, v[key], childPath(path, key)); err != nil {
+				return err
+			}
+			continue
+		}
+		additional, ok := sc["additionalProperties"]
+		if !ok {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok && !allowed {
+			return MetaValidationError{Path: childPath(path, key), Message: "field is not allowed"}
+		}
+		if err := s.validate(additional, v[key], childPath(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateArray(sc map[string]interface{}, v []interface{}, path string) error {
+	if min, ok := sc["minItems"].(float64); ok && float64(len(v)) < min {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("expected at least %v items but got %d", min, len(v))}
+	}
+	if max, ok := sc["maxItems"].(float64); ok && float64(len(v)) > max {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("expected at most %v items but got %d", max, len(v))}
+	}
+	if unique, ok := sc["uniqueItems"].(bool); ok && unique {
+		for i := range v {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(v[i], v[j]) {
+					return MetaValidationError{Path: fmt.Sprintf("%s[%d]", path, i), Message: fmt.Sprintf("item is a duplicate of item %d", j)}
+				}
+			}
+		}
+	}
+	if items, ok := sc["items"]; ok {
+		for i, item := range v {
+			if err := s.validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Schema) validateString(sc map[string]interface{}, v string, path string) error {
+	length := utf8.RuneCountInString(v)
+	if min, ok := sc["minLength"].(float64); ok && float64(length) < min {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("expected at least %v characters but got %d", min, length)}
+	}
+	if max, ok := sc["maxLength"].(float64); ok && float64(length) > max {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("expected at most %v characters but got %d", max, length)}
+	}
+	if pattern, ok := sc["pattern"].(string); ok {
+		if !s.patterns[pattern].MatchString(v) {
+			return MetaValidationError{Path: path, Message: fmt.Sprintf("value does not match the pattern `%s'", pattern)}
+		}
+	}
+	return nil
+}
+
+func validateNumber(sc map[string]interface{}, v float64, path string) error {
+	if min, ok := sc["minimum"].(float64); ok && v < min {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("value must be greater than or equal to %v", min)}
+	}
+	if max, ok := sc["maximum"].(float64); ok && v > max {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("value must be less than or equal to %v", max)}
+	}
+	if min, ok := sc["exclusiveMinimum"].(float64); ok && v <= min {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("value must be greater than %v", min)}
+	}
+	if max, ok := sc["exclusiveMaximum"].(float64); ok && v >= max {
+		return MetaValidationError{Path: path, Message: fmt.Sprintf("value must be less than %v", max)}
+	}
+	return nil
+}
+
+func (s *Schema) validateCombinations(sc map[string]interface{}, v interface{}, path string) error {
+	if allOf, ok := sc["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			if err := s.validate(sub, v, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if anyOf, ok := sc["anyOf"].([]interface{}); ok && len(anyOf) > 0 {
+		matched := false
+		var firstErr error
+		for _, sub := range anyOf {
+			err := s.validate(sub, v, path)
+			if err == nil {
+				matched = true
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if !matched {
+			return firstErr
+		}
+	}
+
+	if oneOf, ok := sc["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if s.validate(sub, v, path) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return MetaValidationError{Path: path, Message: fmt.Sprintf("value must match exactly one schema but matches %d", matches)}
+		}
+	}
+
+	if not, ok := sc["not"]; ok && s.validate(not, v, path) == nil {
+		return MetaValidationError{Path: path, Message: "value must not match the schema"}
+	}
+	return nil
+}
+
+// resolve resolves a local reference (e.g. #/definitions/job)
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference `%s'", ref)
+	}
+	current := s.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.Replace(strings.Replace(part, "~1", "/", -1), "~0", "~", -1)
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unable to resolve reference `%s'", ref)
+		}
+		if current, ok = m[part]; !ok {
+			return nil, fmt.Errorf("unable to resolve reference `%s'", ref)
+		}
+	}
+	return current, nil
+}
+
+func matchesType(t interface{}, v interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, v)
+	case []interface{}:
+		for _, e := range tt {
+			if s, ok := e.(string); ok && matchesSingleType(s, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(t string, v interface{}) bool {
+	switch t {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && math.Trunc(f) == f
+	default:
+		return jsonType(v) == t
+	}
+}
+
+func describeType(t interface{}) string {
+	switch tt := t.(type) {
+	case string:
+		return tt
+	case []interface{}:
+		types := make([]string, 0, len(tt))
+		for _, e := range tt {
+			types = append(types, fmt.Sprint(e))
+		}
+		return strings.Join(types, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+var regexIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func childPath(path, key string) string {
+	if regexIdentifier.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
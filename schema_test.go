@@ -0,0 +1,125 @@
+package githubcomment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{
+		"type": "object",
+		"required": ["coverage"],
+		"additionalProperties": false,
+		"properties": {
+			"coverage": {"type": "number", "minimum": 0, "maximum": 100},
+			"status": {"enum": ["pending", "success", "failure"]},
+			"jobs": {"type": "array", "items": {"$ref": "#/definitions/job"}},
+			"build id": {"type": "integer"}
+		},
+		"definitions": {
+			"job": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {"name": {"type": "string", "minLength": 1}}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		Meta  interface{}
+		Error string
+	}{
+		{map[string]interface{}{"coverage": 90}, ""},
+		{map[string]interface{}{"coverage": 90, "status": "success", "jobs": []interface{}{map[string]interface{}{"name": "lint"}}}, ""},
+		{nil, "invalid meta at `meta': expected object but got null"},
+		{map[string]interface{}{}, "invalid meta at `meta.coverage': field is required"},
+		{map[string]interface{}{"coverage": "90"}, "invalid meta at `meta.coverage': expected number but got string"},
+		{map[string]interface{}{"coverage": 101}, "invalid meta at `meta.coverage': value must be less than or equal to 100"},
+		{map[string]interface{}{"coverage": 90, "status": "done"}, "invalid meta at `meta.status': value must be one of [\"pending\",\"success\",\"failure\"]"},
+		{map[string]interface{}{"coverage": 90, "jobs": []interface{}{map[string]interface{}{"name": "lint"}, map[string]interface{}{"name": ""}}}, "invalid meta at `meta.jobs[1].name': expected at least 1 characters but got 0"},
+		{map[string]interface{}{"coverage": 90, "build id": 1.5}, "invalid meta at `meta[\"build id\"]': expected integer but got number"},
+		{map[string]interface{}{"coverage": 90, "covrage": 90}, "invalid meta at `meta.covrage': field is not allowed"},
+		{struct {
+			Coverage float64 `json:"coverage"`
+		}{50}, ""},
+	}
+
+	for _, test := range tests {
+		err := schema.Validate(test.Meta)
+		if test.Error != "" {
+			require.EqualError(t, err, test.Error)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestSchemaCombinations(t *testing.T) {
+	schema, err := ParseSchema([]byte(`{"anyOf": [{"type": "string", "pattern": "^v[0-9]+$"}, {"type": "null"}], "not": {"const": "v0"}}`))
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate("v1"))
+	require.NoError(t, schema.Validate(nil))
+	require.EqualError(t, schema.Validate("1"), "invalid meta at `meta': value does not match the pattern `^v[0-9]+$'")
+	require.EqualError(t, schema.Validate("v0"), "invalid meta at `meta': value must not match the schema")
+}
+
+func TestParseSchema(t *testing.T) {
+	_, err := ParseSchema([]byte(`[]`))
+	require.EqualError(t, err, "unable to parse schema: schema must be an object or a boolean")
+
+	_, err = ParseSchema([]byte(`{"$ref": "#"}`))
+	require.EqualError(t, err, "unable to parse schema: cyclic reference")
+	_, err = ParseSchema([]byte(`{"definitions": {"a": {"$ref": "#/definitions/a"}}}`))
+	require.EqualError(t, err, "unable to parse schema: cyclic reference")
+	_, err = ParseSchema([]byte(`{"definitions": {"a": {"allOf": [{"$ref": "#/definitions/b"}]}, "b": {"not": {"$ref": "#/definitions/a"}}}}`))
+	require.EqualError(t, err, "unable to parse schema: cyclic reference")
+	_, err = ParseSchema([]byte(`{"properties": {"a": {"$ref": "#/definitions/missing"}}}`))
+	require.EqualError(t, err, "unable to parse schema: unable to resolve reference `#/definitions/missing'")
+	_, err = ParseSchema([]byte(`{"properties": {"a": {"pattern": "("}}}`))
+	require.EqualError(t, err, "unable to parse schema: invalid pattern `(': error parsing regexp: missing closing ): `(`")
+
+	// unsupported forms of keywords are rejected instead of being ignored
+	_, err = ParseSchema([]byte(`{"properties": {"pair": {"type": "array", "items": [{"type": "string"}, {"type": "number"}]}}}`))
+	require.EqualError(t, err, "unable to parse schema: the array form of `items' is not supported, use a schema")
+	_, err = ParseSchema([]byte(`{"properties": {"coverage": {"minimum": 0, "exclusiveMinimum": true}}}`))
+	require.EqualError(t, err, "unable to parse schema: the boolean form of `exclusiveMinimum' is not supported, use a number")
+	_, err = ParseSchema([]byte(`{"maxLength": "10"}`))
+	require.EqualError(t, err, "unable to parse schema: `maxLength' must be a number")
+
+	// references that validate nested values are fine
+	schema, err := ParseSchema([]byte(`{"properties": {"name": {"type": "string", "pattern": "^[a-z]+$"}, "children": {"items": {"$ref": "#"}}}}`))
+	require.NoError(t, err)
+	require.NoError(t, schema.Validate(map[string]interface{}{"name": "a", "children": []interface{}{map[string]interface{}{"name": "b"}}}))
+	require.EqualError(t, schema.Validate(map[string]interface{}{"children": []interface{}{map[string]interface{}{"name": "B"}}}),
+		"invalid meta at `meta.children[0].name': value does not match the pattern `^[a-z]+$'")
+}
+
+func TestGithubCommentMetaSchema(t *testing.T) {
+	_, gc := newFakeGithub(t, "")
+	_, err := gc.PostIssueComment(1, ID("coverage"), "Hello World", map[string]interface{}{"coverage": "high"})
+	require.NoError(t, err)
+
+	gc.MetaSchema, err = ParseSchema([]byte(`{"properties": {"coverage": {"type": "number"}}}`))
+	require.NoError(t, err)
+
+	// comments with invalid meta can still be read
+	info, err := gc.GetIssueComment(1, ID("coverage"))
+	require.NoError(t, err)
+	require.Equal(t, "invalid meta at `meta.coverage': expected number but got string", info.MetaError)
+
+	// writing invalid meta fails
+	_, err = gc.UpdateIssueComment(1, ID("coverage"), "Hello World", map[string]interface{}{"coverage": "low"})
+	require.EqualError(t, err, "invalid meta at `meta.coverage': expected number but got string")
+
+	// but the meta can be replaced
+	_, err = gc.SetIssueCommentMeta(1, ID("coverage"), map[string]interface{}{"coverage": 90})
+	require.NoError(t, err)
+	info, err = gc.GetIssueComment(1, ID("coverage"))
+	require.NoError(t, err)
+	require.Empty(t, info.MetaError)
+	require.Equal(t, "Hello World", info.Body)
+	require.Equal(t, map[string]interface{}{"coverage": float64(90)}, info.Meta)
+}
@@ -0,0 +1,46 @@
+package githubcomment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// sign returns the signature for the raw body of a comment, the signature covers the scope (owner/repo#issue),
+// the header (which holds the id and the meta) and the hash of the body, so a signed comment cannot be copied to another issue
+func sign(key []byte, scope, raw string) string {
+	header, body := raw, ""
+	if end := strings.IndexRune(raw, '\n'); end != -1 {
+		header, body = raw[:end], raw[end+1:]
+	}
+	header = strings.TrimSpace(header)
+	if matches := regexSignature.FindStringSubmatch(header); len(matches) == 2 {
+		header = header[:len(header)-len(matches[0])]
+	}
+	bodyHash := sha256.Sum256([]byte(body))
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(scope))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(header))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature reports whether the raw body of a comment carries a valid signature for the scope
+func verifySignature(key []byte, scope, raw string) bool {
+	info, err := ParseInfo(raw)
+	if err != nil || info.Signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(info.Signature), []byte(sign(key, scope, raw)))
+}
+
+// signatureScope returns the scope that is signed for comments of the issue,
+// owner and repository are lowercased because github treats them case insensitive
+func (gc *GithubComment) signatureScope(issueID int) string {
+	return strings.ToLower(fmt.Sprintf("%s/%s#%d", gc.Owner, gc.Repository, issueID))
+}
@@ -0,0 +1,106 @@
+package githubcomment
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	key := []byte("secret")
+	info := Info{ID: ID("deploy"), Body: "Hello World", Meta: map[string]interface{}{"env": "prod"}}
+	raw, err := info.Build()
+	require.NoError(t, err)
+
+	const scope = "owner/repo#1"
+	info.Signature = sign(key, scope, raw)
+	signed, err := info.Build()
+	require.NoError(t, err)
+	require.True(t, verifySignature(key, scope, signed))
+	require.False(t, verifySignature([]byte("other"), scope, signed))
+	require.False(t, verifySignature(key, scope, raw))
+
+	// tampering with the meta or the body invalidates the signature
+	require.False(t, verifySignature(key, scope, strings.Replace(signed, "prod", "dev", 1)))
+	require.False(t, verifySignature(key, scope, strings.Replace(signed, "Hello", "Bye", 1)))
+
+	// the signature is only valid on the issue it was written to
+	require.False(t, verifySignature(key, "owner/repo#2", signed))
+	require.False(t, verifySignature(key, "owner/other#1", signed))
+
+	parsed, err := ParseInfo(signed)
+	require.NoError(t, err)
+	require.Equal(t, info.Signature, parsed.Signature)
+	require.Equal(t, map[string]interface{}{"env": "prod"}, parsed.Meta)
+	require.Equal(t, "Hello World", parsed.Body)
+}
+
+func TestSignedComments(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.SigningKey = []byte("secret")
+
+	// a spoofed comment
+	spoofed := f.addComment("attacker", makeMagicMarker(ID("deploy"))+"<!---{\"env\":\"evil\"}--->\nHello World")
+
+	info, err := gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.False(t, info.Verified)
+
+	gc.SkipUnverified = true
+	_, err = gc.GetIssueComment(1, ID("deploy"))
+	require.Equal(t, IssueCommentNotFoundError{ID: ID("deploy")}, err)
+
+	result, err := gc.UpdateIssueComment(1, ID("deploy"), "Hello World", map[string]interface{}{"env": "prod"})
+	require.NoError(t, err)
+	require.Equal(t, ActionCreated, result.Action)
+	require.NotEqual(t, spoofed.GetID(), result.CommentID)
+
+	info, err = gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.True(t, info.Verified)
+	require.Equal(t, result.CommentID, info.CommentID)
+	require.Equal(t, map[string]interface{}{"env": "prod"}, info.Meta)
+}
+
+func TestSignedCommentCopiedToOtherIssue(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.SigningKey = []byte("secret")
+	gc.SkipUnverified = true
+
+	// a body that was validly signed for another pull request
+	body, err := gc.buildInfo(2, &Info{ID: ID("deploy"), Body: "Hello World"})
+	require.NoError(t, err)
+	copied := f.addComment("bot", body)
+
+	_, err = gc.GetIssueComment(1, ID("deploy"))
+	require.Equal(t, IssueCommentNotFoundError{ID: ID("deploy")}, err)
+
+	// addressing the comment directly does not skip the check
+	_, err = gc.GetIssueCommentByID(copied.GetID())
+	require.Equal(t, UnverifiedCommentError{CommentID: copied.GetID()}, err)
+
+	result, err := gc.PostIssueComment(1, ID("deploy"), "Hello World", nil)
+	require.NoError(t, err)
+	info, err := gc.GetIssueCommentByID(result.CommentID)
+	require.NoError(t, err)
+	require.True(t, info.Verified)
+}
+
+func TestSignatureScopeIgnoresCase(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.SigningKey = []byte("secret")
+	gc.SkipUnverified = true
+
+	// signed with another casing of owner and repository
+	gc.Owner, gc.Repository = "Owner", "Repo"
+	body, err := gc.buildInfo(1, &Info{ID: ID("deploy"), Body: "Hello World"})
+	require.NoError(t, err)
+	signed := f.addComment("bot", body)
+
+	gc.Owner, gc.Repository = "owner", "repo"
+	info, err := gc.GetIssueComment(1, ID("deploy"))
+	require.NoError(t, err)
+	require.True(t, info.Verified)
+	require.Equal(t, signed.GetID(), info.CommentID)
+}
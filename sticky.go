@@ -0,0 +1,57 @@
+package githubcomment
+
+import (
+	"github.com/google/go-github/github"
+)
+
+// hasNewerIssueComments reports whether other comments were posted after the comment,
+// the order of the list is used because the timestamps of github only have a resolution of seconds
+func (gc *GithubComment) hasNewerIssueComments(issueID int, comment *github.IssueComment) (bool, error) {
+	found, newer := false, false
+	err := gc.eachIssueComment(issueID, func(c *github.IssueComment) bool {
+		if found {
+			newer = true
+			return false
+		}
+		found = c.GetID() == comment.GetID()
+		return true
+	})
+	return newer, err
+}
+
+// repostIssueComment posts the info as a new comment and deletes the old comment
+func (gc *GithubComment) repostIssueComment(issueID int, old *github.IssueComment, info *Info) (*Result, error) {
+	bodyText, err := gc.buildInfo(issueID, info)
+	if err != nil {
+		return nil, err
+	}
+	if gc.DryRun {
+		info.setComment(old)
+		return &Result{
+			ID:           info.ID,
+			CommentID:    old.GetID(),
+			HTMLURL:      old.GetHTMLURL(),
+			Action:       ActionReposted,
+			Info:         info,
+			DryRun:       true,
+			Body:         bodyText,
+			PreviousBody: old.GetBody(),
+		}, nil
+	}
+	comment, _, err := gc.Client.Issues.CreateComment(gc.Context, gc.Owner, gc.Repository, issueID, &github.IssueComment{
+		Body: &bodyText,
+	})
+	if err != nil {
+		return nil, apiError(err)
+	}
+	info.setComment(comment)
+	result := &Result{
+		ID:        info.ID,
+		CommentID: comment.GetID(),
+		HTMLURL:   comment.GetHTMLURL(),
+		Action:    ActionReposted,
+		Info:      info,
+	}
+	_, err = gc.Client.Issues.DeleteComment(gc.Context, gc.Owner, gc.Repository, old.GetID())
+	return result, apiError(err)
+}
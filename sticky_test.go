@@ -0,0 +1,46 @@
+package githubcomment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStickyBottom(t *testing.T) {
+	f, gc := newFakeGithub(t, "")
+	gc.StickyBottom = true
+
+	first, err := gc.UpdateIssueComment(1, ID("status"), "first", map[string]interface{}{"run": 1})
+	require.NoError(t, err)
+	require.Equal(t, ActionCreated, first.Action)
+
+	// no other comments, edit in place
+	second, err := gc.UpdateIssueComment(1, ID("status"), "second", map[string]interface{}{"run": 2})
+	require.NoError(t, err)
+	require.Equal(t, ActionUpdated, second.Action)
+	require.Equal(t, first.CommentID, second.CommentID)
+
+	// posted in the same second as the comment
+	lgtm := f.addComment("reviewer", "LGTM")
+	lgtm.CreatedAt = f.comments[0].CreatedAt
+
+	// an unchanged comment stays where it is
+	unchanged, err := gc.UpdateIssueComment(1, ID("status"), "second", map[string]interface{}{"run": 2})
+	require.NoError(t, err)
+	require.Equal(t, ActionUnchanged, unchanged.Action)
+	require.Equal(t, first.CommentID, unchanged.CommentID)
+
+	third, err := gc.UpdateIssueComment(1, ID("status"), "third", map[string]interface{}{"run": 3})
+	require.NoError(t, err)
+	require.Equal(t, ActionReposted, third.Action)
+	require.NotEqual(t, first.CommentID, third.CommentID)
+
+	require.Len(t, f.comments, 2)
+	require.Equal(t, "LGTM", f.comments[0].GetBody())
+	require.Equal(t, third.CommentID, f.comments[1].GetID())
+
+	info, err := gc.GetIssueComment(1, ID("status"))
+	require.NoError(t, err)
+	require.Equal(t, "third", info.Body)
+	require.Equal(t, map[string]interface{}{"run": float64(3)}, info.Meta)
+}
@@ -1,14 +1,27 @@
 package githubcomment
 
 import (
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 	"unicode"
 
 	"github.com/google/uuid"
 )
 
+// ID identifies a comment.
+// IDs that only consist of letters, numbers and '-' are used as they are in the marker,
+// all other IDs are written as their legacy form followed by a '.' and the base32 encoding
+// of the full id, so any string can be used without two ids sharing the same marker.
 type ID string
 
+var idEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GetID returns the legacy form of the id, every rune that is not a letter, number or '-' is stripped.
+// If the id is empty a new uuid will be returned.
 func (i ID) GetID() string {
 	var sb strings.Builder
 
@@ -19,7 +32,7 @@ func (i ID) GetID() string {
 	}
 
 	for i := 0; i < len(runes); i++ {
-		if !unicode.IsNumber(runes[i]) && !unicode.IsLetter(runes[i]) && runes[i] != '-' {
+		if !isLegacyIDRune(runes[i]) {
 			continue
 		}
 		sb.WriteRune(runes[i])
@@ -27,3 +40,58 @@ func (i ID) GetID() string {
 
 	return sb.String()
 }
+
+// Canonical returns the representation of the id that is used in the marker.
+// If the id is empty a new uuid will be returned.
+func (i ID) Canonical() string {
+	legacy := i.GetID()
+	if i == "" || legacy == string(i) {
+		return legacy
+	}
+	return legacy + "." + strings.ToLower(idEncoding.EncodeToString([]byte(i)))
+}
+
+// Collides reports whether both ids have the same legacy form,
+// which means comments that were created by earlier versions cannot be told apart.
+func (i ID) Collides(other ID) bool {
+	return i != "" && other != "" && i != other && i.GetID() == other.GetID()
+}
+
+// ParseID parses the canonical representation of an id
+func ParseID(s string) (ID, error) {
+	dot := strings.LastIndexByte(s, '.')
+	if dot == -1 {
+		if ID(s).GetID() != s {
+			return "", errors.New("id mismatch")
+		}
+		return ID(s), nil
+	}
+	raw, err := idEncoding.DecodeString(strings.ToUpper(s[dot+1:]))
+	if err != nil {
+		return "", fmt.Errorf("invalid id encoding: %v", err)
+	}
+	id := ID(raw)
+	if id.Canonical() != s {
+		return "", errors.New("id mismatch")
+	}
+	return id, nil
+}
+
+func isLegacyIDRune(r rune) bool {
+	return unicode.IsNumber(r) || unicode.IsLetter(r) || r == '-'
+}
+
+// sameJSON reports whether both values have the same json representation
+func sameJSON(a, b interface{}) bool {
+	var normalized [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if err := json.Unmarshal(buf, &normalized[i]); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(normalized[0], normalized[1])
+}
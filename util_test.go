@@ -0,0 +1,59 @@
+package githubcomment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDCanonical(t *testing.T) {
+	tests := []struct {
+		ID        ID
+		Canonical string
+	}{
+		{ID("123-ABC"), "123-ABC"},
+		{ID("ÖÄL"), "ÖÄL"},
+		{ID("ci/lint"), "cilint.mnus63djnz2a"},
+		{ID("build:linux_amd64"), "buildlinuxamd64.mj2ws3dehjwgs3tvpbpwc3legy2a"},
+		{ID("/"), ".f4"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.Canonical, test.ID.Canonical())
+		id, err := ParseID(test.Canonical)
+		require.NoError(t, err)
+		require.Equal(t, test.ID, id)
+	}
+
+	require.NotEqual(t, ID("ci/lint").Canonical(), ID("cilint").Canonical())
+	require.NotEmpty(t, ID("").Canonical())
+}
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		Input string
+		Error string
+	}{
+		{"", "id mismatch"},
+		{"ci/lint", "id mismatch"},
+		{"cilint.mnus63djnz2a", ""},
+		{"cilint.mnuxi", "id mismatch"},
+		{"cilint.1", "invalid id encoding: illegal base32 data at input byte 0"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseID(test.Input)
+		if test.Error != "" {
+			require.EqualError(t, err, test.Error)
+		} else {
+			require.NoError(t, err)
+		}
+	}
+}
+
+func TestIDCollides(t *testing.T) {
+	require.True(t, ID("ci/lint").Collides(ID("cilint")))
+	require.True(t, ID("ci/lint").Collides(ID("ci lint")))
+	require.False(t, ID("ci/lint").Collides(ID("ci/lint")))
+	require.False(t, ID("ci/lint").Collides(ID("ci-lint")))
+}